@@ -2,11 +2,14 @@ package assets
 
 import (
 	"embed"
+	"errors"
 	"fmt"
+	"io/fs"
+	"path"
+	"strings"
 
+	"github.com/NautiluX/8bites/pkg/sprites"
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/audio"
-	"github.com/hajimehoshi/ebiten/v2/audio/wav"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
@@ -20,8 +23,13 @@ const (
 )
 
 //go:embed sprites/**/*.png
+//go:embed sprites/**/*.aseprite
 //go:embed sfx/*.wav
+//go:embed sfx/*.dmf
+//go:embed sfx/*.sfxr
 //go:embed maps/*.txt
+//go:embed levels.json
+//go:embed achievements.json
 var folder embed.FS
 
 func GetPlayerYellowSprite() (*ebiten.Image, error) {
@@ -45,51 +53,59 @@ func GetItem(item string) (*ebiten.Image, error) {
 }
 
 func GetSprite(path string) (*ebiten.Image, error) {
-	img, _, err := ebitenutil.NewImageFromFileSystem(folder, "sprites/"+path)
+	img, _, err := ebitenutil.NewImageFromFileSystem(files, "sprites/"+path)
 	if err != nil {
 		return nil, err
 	}
 	return img, nil
 }
 
-var audioContext *audio.Context
-
-func GetBackgroundMusic(soundtrack string) (*audio.Player, error) {
-	return GetSfx(soundtrack, true)
-}
-
-func GetSfx(name string, infinite bool) (*audio.Player, error) {
-	reader, err := folder.Open("sfx/" + name + ".wav")
+// GetAnimations reads the animations of a sprite sheet from the .aseprite file
+// it was exported from, e.g. items/donut.aseprite for items/donut.png.
+func GetAnimations(sheet string) ([]sprites.Animation, error) {
+	source := strings.TrimSuffix(sheet, ".png") + ".aseprite"
+	file, err := files.Open("sprites/" + source)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
-	stream, err := wav.DecodeWithSampleRate(44100, reader)
-
-	if audioContext == nil {
-		audioContext = audio.NewContext(44100)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	player, err := audioContext.NewPlayer(stream)
+	aseprite, err := sprites.ParseAseprite(file)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
 	}
+	return aseprite.Animations(path.Base(strings.TrimSuffix(source, ".aseprite"))), nil
+}
 
-	if infinite {
-		s := audio.NewInfiniteLoop(stream, stream.Length())
-		player, err = audioContext.NewPlayer(s)
+// soundFormats are the file extensions of sounds, in the order they are
+// looked for. Rendered WAV files are preferred over synthesized sounds.
+var soundFormats = []string{"wav", "sfxr", "dmf"}
+
+// GetSound returns the file of a sound effect or soundtrack, and its format.
+func GetSound(name string) ([]byte, string, error) {
+	for _, format := range soundFormats {
+		data, err := fs.ReadFile(files, "sfx/"+name+"."+format)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
+		return data, format, nil
 	}
-	return player, nil
+	return nil, "", fmt.Errorf("sound %s: %w", name, fs.ErrNotExist)
+}
+
+func GetLevelConfig() ([]byte, error) {
+	return fs.ReadFile(files, "levels.json")
+}
+
+func GetAchievementConfig() ([]byte, error) {
+	return fs.ReadFile(files, "achievements.json")
 }
 
 func GetMapTiles(name string) ([15][20]int, error) {
-	file, err := folder.Open("maps/" + name + ".txt")
+	file, err := files.Open("maps/" + name + ".txt")
 	if err != nil {
 		return [15][20]int{}, err
 	}
@@ -109,7 +125,6 @@ func GetMapTiles(name string) ([15][20]int, error) {
 					return [15][20]int{}, fmt.Errorf("error reading map file: %w", err)
 				}
 			}
-			fmt.Print(string(buf))
 			var tile int
 			_, err = fmt.Sscanf(string(buf), "%d", &tile)
 			if err != nil {
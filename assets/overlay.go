@@ -0,0 +1,59 @@
+package assets
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// ModDirEnv is the environment variable that points to a directory with
+// replacement assets. Files found there take precedence over the embedded ones.
+const ModDirEnv = "EIGHTBITES_MOD_DIR"
+
+// overlayFS looks up files in upper first and falls back to lower if they
+// don't exist there.
+type overlayFS struct {
+	upper fs.FS
+	lower fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	file, err := o.upper.Open(name)
+	if err == nil {
+		return file, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return o.lower.Open(name)
+}
+
+var (
+	files  fs.FS = folder
+	modDir string
+)
+
+func init() {
+	if dir := os.Getenv(ModDirEnv); dir != "" {
+		SetModDir(dir)
+	}
+}
+
+// SetModDir makes all Get* functions look for assets in dir before falling back
+// to the embedded files. The directory uses the same layout as the embedded
+// assets, e.g. sprites/items/donut.png or maps/level_1.txt.
+// An empty dir disables the overlay.
+func SetModDir(dir string) {
+	modDir = dir
+	if dir == "" {
+		files = folder
+		return
+	}
+	files = overlayFS{upper: os.DirFS(dir), lower: folder}
+}
+
+// ModDir returns the directory set with SetModDir, or an empty string if no
+// overlay is in use.
+func ModDir() string {
+	return modDir
+}
@@ -0,0 +1,74 @@
+package assets
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Watcher polls the mod directory for changed files. It compares modification
+// times, so it works without any platform specific file notification APIs.
+type Watcher struct {
+	dir      string
+	modTimes map[string]time.Time
+}
+
+// NewWatcher returns a watcher for the current mod directory, or nil if no mod
+// directory is in use.
+func NewWatcher() (*Watcher, error) {
+	if modDir == "" {
+		return nil, nil
+	}
+	w := &Watcher{dir: modDir}
+	modTimes, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+	w.modTimes = modTimes
+	return w, nil
+}
+
+// Poll returns the paths of all files that were added, changed or removed since
+// the last call. Paths use the same layout as the embedded assets, e.g.
+// sprites/items/donut.png.
+func (w *Watcher) Poll() ([]string, error) {
+	modTimes, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for path, modTime := range modTimes {
+		if last, ok := w.modTimes[path]; !ok || !last.Equal(modTime) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range w.modTimes {
+		if _, ok := modTimes[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	w.modTimes = modTimes
+	return changed, nil
+}
+
+func (w *Watcher) scan() (map[string]time.Time, error) {
+	modTimes := map[string]time.Time{}
+	err := fs.WalkDir(os.DirFS(w.dir), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		modTimes[path] = info.ModTime()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return modTimes, nil
+}
@@ -0,0 +1,138 @@
+// Command botbench lets the built-in bot play every level with many seeds and
+// reports how it did, to help balancing levels before shipping them.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NautiluX/8bites/pkg/game"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	seeds    = flag.Int("seeds", 100, "number of seeds to play per level")
+	level    = flag.String("level", "", "name of the level to play, all levels if empty")
+	maxTime  = flag.Duration("max-time", 5*time.Minute, "game time after which a run counts as timed out")
+	firstRun = flag.Uint64("first-seed", 1, "seed of the first run")
+)
+
+type result struct {
+	won        bool
+	points     int
+	ticks      int
+	deathCause string
+}
+
+type summary struct {
+	runs        int
+	wins        int
+	points      int
+	winTicks    int
+	deathCauses map[string]int
+}
+
+// play lets the bot play a level until it is won, lost or timed out.
+func play(a *game.Assets, levelIndex int, seed uint64, maxTicks int) (result, error) {
+	g, err := game.NewGame(game.Config{
+		Mode:       game.ModeSingle,
+		Assets:     a,
+		Seed:       seed,
+		Headless:   true,
+		Bot:        true,
+		StartLevel: levelIndex,
+	})
+	if err != nil {
+		return result{}, err
+	}
+	for !g.Ended && g.LevelTicks() < maxTicks {
+		err := g.Tick([]game.Input{g.BotInput(0)})
+		if err != nil {
+			return result{}, err
+		}
+	}
+	player := g.Players()[0]
+	r := result{
+		won:    g.Ended && !g.Lost,
+		points: player.Points,
+		ticks:  g.LevelTicks(),
+	}
+	switch {
+	case g.Lost:
+		r.deathCause = player.DeathCause
+	case !g.Ended:
+		r.deathCause = "timeout"
+	}
+	return r, nil
+}
+
+func main() {
+	flag.Parse()
+
+	a, err := game.LoadAssets()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tps := ebiten.TPS()
+	maxTicks := int(maxTime.Seconds()) * tps
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LEVEL\tRUNS\tWIN RATE\tAVG SCORE\tAVG TIME TO CLEAR\tLOSSES")
+	for i, l := range a.Levels {
+		if *level != "" && l.Name != *level {
+			continue
+		}
+		s := summary{deathCauses: map[string]int{}}
+		for n := range *seeds {
+			r, err := play(a, i, *firstRun+uint64(n), maxTicks)
+			if err != nil {
+				log.Fatalf("failed to play level %s: %v", l.Name, err)
+			}
+			s.runs++
+			s.points += r.points
+			if r.won {
+				s.wins++
+				s.winTicks += r.ticks
+			} else {
+				s.deathCauses[r.deathCause]++
+			}
+		}
+
+		avgTime := "-"
+		if s.wins > 0 {
+			avgTime = (time.Duration(s.winTicks/s.wins) * time.Second / time.Duration(tps)).Round(100 * time.Millisecond).String()
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%d\t%s\t%s\n", l.Name, s.runs, float64(s.wins)*100/float64(s.runs), s.points/s.runs, avgTime, formatCauses(s.deathCauses))
+	}
+	w.Flush()
+}
+
+// formatCauses lists the death causes, most frequent first.
+func formatCauses(causes map[string]int) string {
+	if len(causes) == 0 {
+		return "-"
+	}
+	names := make([]string, 0, len(causes))
+	for name := range causes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if causes[names[i]] != causes[names[j]] {
+			return causes[names[i]] > causes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	text := ""
+	for i, name := range names {
+		if i > 0 {
+			text += ", "
+		}
+		text += fmt.Sprintf("%dx %s", causes[name], name)
+	}
+	return text
+}
@@ -0,0 +1,56 @@
+// Command leaderboard runs the high score server of 8bites. Every submitted
+// score is checked by simulating the replay sent along with it.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/NautiluX/8bites/pkg/game"
+	"github.com/NautiluX/8bites/pkg/leaderboard"
+)
+
+var (
+	addr   = flag.String("addr", ":8080", "address to listen on")
+	dbPath = flag.String("db", "scores.json", "file the scores are stored in")
+)
+
+func main() {
+	flag.Parse()
+
+	a, err := game.LoadAssets()
+	if err != nil {
+		log.Fatal(err)
+	}
+	store, err := leaderboard.OpenFileStore(*dbPath)
+	if err != nil {
+		log.Fatalf("failed to open score file: %v", err)
+	}
+
+	// Replays are simulated one after the other, to keep the load of the server predictable.
+	var mu sync.Mutex
+	verify := func(s leaderboard.Submission) (int, error) {
+		var replay game.Replay
+		err := json.Unmarshal(s.Replay, &replay)
+		if err != nil {
+			return 0, fmt.Errorf("invalid replay: %w", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		points, err := game.VerifyReplay(a, replay)
+		if err != nil {
+			return 0, err
+		}
+		if s.Player < 0 || s.Player >= len(points) {
+			return 0, fmt.Errorf("replay has no player %d", s.Player)
+		}
+		return points[s.Player], nil
+	}
+
+	log.Printf("Listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, leaderboard.NewServer(store, verify)))
+}
@@ -3,6 +3,7 @@ module github.com/NautiluX/8bites
 go 1.24.2
 
 require (
+	github.com/ebitengine/oto/v3 v3.4.0
 	github.com/hajimehoshi/ebiten v1.12.13
 	github.com/hajimehoshi/ebiten/v2 v2.9.4
 )
@@ -10,7 +11,6 @@ require (
 require (
 	github.com/ebitengine/gomobile v0.0.0-20250923094054-ea854a63cce1 // indirect
 	github.com/ebitengine/hideconsole v1.0.0 // indirect
-	github.com/ebitengine/oto/v3 v3.4.0 // indirect
 	github.com/ebitengine/purego v0.9.0 // indirect
 	github.com/go-text/typesetting v0.3.0 // indirect
 	github.com/hajimehoshi/oto v0.6.8 // indirect
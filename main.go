@@ -1,574 +1,110 @@
 package main
 
 import (
-	"bytes"
-	"fmt"
-	"image/color"
-	_ "image/png"
+	"flag"
 	"log"
-	"math"
 	"math/rand/v2"
-	"strings"
-	"time"
+	"os"
+	"path/filepath"
 
 	"github.com/NautiluX/8bites/assets"
-	"github.com/NautiluX/8bites/pkg/sprites"
+	"github.com/NautiluX/8bites/pkg/env"
+	"github.com/NautiluX/8bites/pkg/game"
+	"github.com/NautiluX/8bites/pkg/netplay"
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/audio"
-	"github.com/hajimehoshi/ebiten/v2/examples/resources/fonts"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
-	"github.com/hajimehoshi/ebiten/v2/text/v2"
 )
 
-const (
-	screenWidth  = 640
-	screenHeight = 480
-	mapWidth     = 640 / 32
-	mapHeight    = 480 / 32
-	playerSpeed  = 2
-)
+// noAudioEnv disables the sound if it is set, for machines without a sound device.
+const noAudioEnv = "EIGHTBITES_NO_AUDIO"
 
 var (
-	levels = []Level{
-		{
-			Name:              "level_1",
-			Tiles:             "level_1",
-			Soundtrack:        "backgroundmusic_1",
-			ReoccurranceRetry: 2,
-			StartEnemies:      3,
-		},
-		{
-			Name:              "level_2",
-			Tiles:             "level_2",
-			Soundtrack:        "backgroundmusic_1",
-			ReoccurranceRetry: 1,
-			StartEnemies:      3,
-		},
-	}
+	modDir   = flag.String("mods", "", "directory with replacement assets, overrides $"+assets.ModDirEnv)
+	gameMode = flag.String("mode", string(game.ModeSingle), "game mode: single, coop or versus")
+	hostAddr = flag.String("host", "", "host a network game on the given address, e.g. :7777")
+	joinAddr = flag.String("join", "", "join the network game hosted on the given address")
+	delay    = flag.Int("delay", 3, "input delay of network games in ticks, from 0 to 255")
+
+	leaderboardURL = flag.String("leaderboard", "", "URL of the leaderboard server to submit scores to")
+	playerName     = flag.String("name", os.Getenv("USER"), "name shown on the leaderboard")
+	bot            = flag.Bool("bot", false, "let the built-in AI play the first player")
+	achievements   = flag.String("achievements", configFile("achievements.json"), "file unlocked achievements are stored in")
+	settings       = flag.String("settings", configFile("settings.json"), "file the audio settings are stored in")
+	noAudio        = flag.Bool("no-audio", os.Getenv(noAudioEnv) != "", "play without sound, e.g. on machines without a sound device, also set by $"+noAudioEnv)
+	envMode        = flag.Bool("env", false, "run as reinforcement learning environment on stdin and stdout, without a window")
 )
 
-type Level struct {
-	Name              string
-	Tiles             string
-	Soundtrack        string
-	ReoccurranceRetry int
-	StartEnemies      int
-}
-
-type Game struct {
-	bgImage      *ebiten.Image
-	playerSprite *sprites.Player
-	enemies      []sprites.CharacterSprite
-	bites        []*sprites.CharacterSprite
-	eatenBites   []sprites.CharacterSprite
-	currentBite  *sprites.CharacterSprite
-	mapTiles     [mapHeight][mapWidth]int
-	wallTile     *ebiten.Image
-	floorTile    *ebiten.Image
-	title        GameTitle
-	Ended        bool
-	Lost         bool
-	CurrentLevel int
-	MusicPlayer  *audio.Player
-}
-
-type GameTitle struct {
-	Visible       bool
-	Duration      time.Duration
-	StartTime     time.Time
-	LastShakeTime time.Time
-	WordsVisible  int
-	ShakeX        int
-	ShakeY        int
-	Text          string
-}
-
-var (
-	theGame *Game
-	font    *text.GoTextFaceSource
-	bites   []*sprites.CharacterSprite
-	enemies []*sprites.CharacterSprite
-)
-
-// init loads the assets before the game starts.
-func init() {
-	var err error
-	font, err = text.NewGoTextFaceSource(bytes.NewReader(fonts.PressStart2P_ttf))
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	bites = []*sprites.CharacterSprite{
-		loadSprite("cheese", sprites.SpriteIdCheese, 9),
-		loadSprite("pizza", sprites.SpriteIdPizza, 10),
-		loadSprite("donut", sprites.SpriteIdDonut, 23),
-		loadSprite("sushi", sprites.SpriteIdSushi, 12),
-		loadSprite("orange", sprites.SpriteIdOrange, 8),
-		loadSprite("avocado", sprites.SpriteIdAvocado, 21),
-		loadSprite("apple", sprites.SpriteIdApple, 20),
-		loadSprite("banana", sprites.SpriteIdBanana, 21),
-	}
-
-	slimeImg, err := assets.GetSlimeSprite()
+// configFile returns the path of the file in the user's config directory, or
+// nothing if there is none.
+func configFile(name string) string {
+	dir, err := os.UserConfigDir()
 	if err != nil {
-		log.Fatalf("failed to load player sprite: %v", err)
-	}
-	slimeSprite := sprites.NewCharacterSprite(slimeImg, 32, 32, []sprites.Animation{
-		{Name: "idle", Frames: 10},
-	}, sprites.SpriteIdSlime)
-
-	enemies = []*sprites.CharacterSprite{slimeSprite}
-	theGame = &Game{}
-	err = ResetGame()
-	if err != nil {
-		log.Fatal(err)
-	}
-}
-
-func loadSprite(spriteName string, spriteId sprites.SpriteId, frames int) *sprites.CharacterSprite {
-	img, err := assets.GetItem(spriteName)
-	if err != nil {
-		log.Fatalf("failed to load donut image: %v", err)
-	}
-	sprite := sprites.NewCharacterSprite(img, 32, 32, []sprites.Animation{
-		{Name: "idle", Frames: frames},
-	}, spriteId)
-	return sprite
-}
-
-func (g *Game) GetRandomFloorPosition(minDistance int) (int, int) {
-	for {
-		x := rand.IntN(mapWidth)
-		y := rand.IntN(mapHeight)
-		if g.mapTiles[y][x] == 0 &&
-			(math.Abs(float64(g.playerSprite.X/32-x*32)) >= float64(minDistance) || math.Abs(float64(g.playerSprite.Y/32-y*32)) >= float64(minDistance)) {
-			return x * 32, y * 32
-		}
-	}
-}
-
-// handleInputAndMovement processes keyboard input and updates the player's position,
-// enforcing screen boundaries.
-func (g *Game) handleInputAndMovement() {
-	// Handle Up/W
-	// Move queued
-	if g.playerSprite.Y%32 == 0 && g.playerSprite.X%32 == 0 && (g.playerSprite.NextVx != 0 || g.playerSprite.NextVy != 0) {
-		g.playerSprite.CurrentVx = g.playerSprite.NextVx
-		g.playerSprite.CurrentVy = g.playerSprite.NextVy
-		g.playerSprite.CurrentAnimation = g.playerSprite.NextAnimation
-		g.playerSprite.NextVx = 0
-		g.playerSprite.NextVy = 0
-	}
-	if ebiten.IsKeyPressed(ebiten.KeyArrowUp) || ebiten.IsKeyPressed(ebiten.KeyW) {
-		if g.playerSprite.CurrentVy != 0 {
-			g.playerSprite.CurrentVy = -playerSpeed
-			g.playerSprite.SetAnimation("up")
-		} else {
-			g.playerSprite.NextVy = -playerSpeed
-			g.playerSprite.SetNextAnimation("up")
-		}
+		return ""
 	}
-	// Handle Down/S
-	if ebiten.IsKeyPressed(ebiten.KeyArrowDown) || ebiten.IsKeyPressed(ebiten.KeyS) {
-		if g.playerSprite.CurrentVy != 0 {
-			g.playerSprite.CurrentVy = playerSpeed
-			g.playerSprite.SetAnimation("down")
-		} else {
-			g.playerSprite.NextVy = playerSpeed
-			g.playerSprite.SetNextAnimation("down")
-		}
-	}
-	// Handle Left/A
-	if ebiten.IsKeyPressed(ebiten.KeyArrowLeft) || ebiten.IsKeyPressed(ebiten.KeyA) {
-		if g.playerSprite.CurrentVx != 0 {
-			g.playerSprite.CurrentVx = -playerSpeed
-			g.playerSprite.SetAnimation("left")
-		} else {
-			g.playerSprite.NextVx = -playerSpeed
-			g.playerSprite.SetNextAnimation("left")
-		}
-	}
-	// Handle Right/D
-	if ebiten.IsKeyPressed(ebiten.KeyArrowRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
-		if g.playerSprite.CurrentVx != 0 {
-			g.playerSprite.CurrentVx = playerSpeed
-			g.playerSprite.SetAnimation("right")
-		} else {
-			g.playerSprite.NextVx = playerSpeed
-			g.playerSprite.SetNextAnimation("right")
-		}
-	}
-
-	for i := range g.enemies {
-		// Random movement for slime. 50% chance to change direction each update
-		slimeSprite := &g.enemies[i]
-		updateMovement := rand.IntN(101)
-		if updateMovement > 75 && slimeSprite.CurrentVx == 0 && slimeSprite.X%32 == 0 && slimeSprite.Y%32 == 0 {
-			slimeSprite.CurrentVx = -1 + rand.IntN(3)
-			slimeSprite.CurrentVy = 0
-		}
-		if updateMovement < 25 && slimeSprite.CurrentVy == 0 && slimeSprite.Y%32 == 0 && slimeSprite.X%32 == 0 {
-			slimeSprite.CurrentVx = 0
-			slimeSprite.CurrentVy = -1 + rand.IntN(3)
-		}
-
-		if !g.checkWallCollision(slimeSprite) {
-			slimeSprite.Move(screenWidth, screenHeight)
-		}
-	}
-	if !g.checkWallCollision(&g.playerSprite.CharacterSprite) {
-		g.playerSprite.Move(screenWidth, screenHeight)
-	}
-}
-
-func (g *Game) checkGameEnd() error {
-	if len(g.eatenBites) >= 8 {
-		// Show title
-		g.title.Visible = true
-		g.title.StartTime = time.Now()
-		g.title.WordsVisible = 0
-		if g.CurrentLevel+1 >= len(levels) {
-			// Game completed
-			g.title.Text = "THE END - GZ!"
-			g.Ended = true
-			return nil
-		}
-		g.title.Text = "YOU WIN! HIT [SPACE]"
-		g.CurrentLevel++
-		g.Ended = true
-		return nil
-	}
-	for _, enemy := range g.enemies {
-		if g.playerSprite.CheckCollision(&enemy) {
-			// Show title
-			g.title.Visible = true
-			g.title.StartTime = time.Now()
-			g.title.WordsVisible = 0
-			g.title.Text = "GAME OVER! HIT [SPACE]"
-			g.Ended = true
-			g.Lost = true
-			player, err := assets.GetSfx("gameover", false)
-			if err != nil {
-				return fmt.Errorf("failed to load game over sfx: %w", err)
-			}
-			g.MusicPlayer.Close()
-			go player.Play()
-			return nil
-		}
-	}
-	return nil
+	return filepath.Join(dir, "8bites", name)
 }
 
-func (g *Game) checkWallCollision(s *sprites.CharacterSprite) bool {
-	// figure out if the current movement would result in a collision with a wal.
-	// we take the sprites current position width and height into account.
-	newX := s.X + s.CurrentVx
-	newY := s.Y + s.CurrentVy
-
-	// Calculate the tile coordinates
-	tileX1 := newX / 32
-	tileY1 := newY / 32
-	tileX2 := (newX + s.Width - 1) / 32
-	tileY2 := (newY + s.Height - 1) / 32
-
-	// Check all tiles the sprite would occupy
-	for y := tileY1; y <= tileY2; y++ {
-		for x := tileX1; x <= tileX2; x++ {
-			if x < 0 || x >= mapWidth || y < 0 || y >= mapHeight {
-				return true // Out of bounds is treated as a wall
-			}
-			if g.mapTiles[y][x] == 1 {
-				return true // Collision with wall
-			}
-		}
+// connect hosts or joins a network game, depending on the flags. The host
+// decides on the seed and the game mode.
+func connect(seed uint64) (*netplay.Session, error) {
+	if *joinAddr != "" {
+		log.Printf("Joining game at %s", *joinAddr)
+		return netplay.Join(*joinAddr)
 	}
-	return false
-}
-
-func (g *Game) animate() {
-	g.playerSprite.Animate()
-	for i := range g.enemies {
-		g.enemies[i].Animate()
+	mode := game.GameMode(*gameMode)
+	if mode == game.ModeSingle {
+		mode = game.ModeVersus
 	}
-	g.currentBite.Animate()
+	log.Printf("Waiting for a player to join on %s", *hostAddr)
+	return netplay.Host(*hostAddr, seed, *delay, string(mode))
 }
 
-var lastAnimationUpdate time.Time
-
-// Update handles the game logic, primarily input and state changes.
-func (g *Game) Update() error {
-
-	if time.Since(lastAnimationUpdate) > 100*time.Millisecond {
-		lastAnimationUpdate = time.Now()
-		g.animate()
-	}
-
-	// Example: Exit on pressing Escape
-	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
-		return ebiten.Termination
+func main() {
+	flag.Parse()
+	if *modDir != "" {
+		assets.SetModDir(*modDir)
 	}
 
-	if g.Ended {
-		if ebiten.IsKeyPressed(ebiten.KeyR) || ebiten.IsKeyPressed(ebiten.KeySpace) {
-			err := ResetGame()
-			if err != nil {
-				log.Fatalf("Failed to reset game: %v", err)
-			}
-		}
-		return nil
-	}
-	err := g.checkGameEnd()
+	a, err := game.LoadAssets()
 	if err != nil {
-		return err
+		log.Fatal(err)
 	}
-	g.checkBiteEaten()
 
-	g.handleInputAndMovement()
-
-	return nil
-}
-
-func (g *Game) hasBiteBeenEaten(bite *sprites.CharacterSprite) bool {
-	for _, eatenBite := range g.eatenBites {
-		if bite.Id == eatenBite.Id {
-			return true
+	if *envMode {
+		e := env.Env{Assets: a}
+		err := e.Serve(os.Stdin, os.Stdout)
+		if err != nil {
+			log.Fatal(err)
 		}
-	}
-	return false
-}
-
-func (g *Game) checkBiteEaten() {
-	if !g.playerSprite.CheckCollision(g.currentBite) {
 		return
 	}
-	if !g.hasBiteBeenEaten(g.currentBite) {
-		g.eatenBites = append(g.eatenBites, *g.currentBite)
-		g.playerSprite.Points += 500 + 100*len(g.enemies)
-		g.placeNewBite()
-		return
-	}
-	g.playerSprite.Points += 100 * len(g.enemies)
-	g.placeNewEnemy()
-	g.placeNewBite()
-}
 
-func ResetGame() error {
-	if theGame.MusicPlayer != nil {
-		// Stop previous music
-		theGame.MusicPlayer.Close()
+	cfg := game.Config{
+		Mode:         game.GameMode(*gameMode),
+		Assets:       a,
+		Seed:         rand.Uint64(),
+		Leaderboard:  *leaderboardURL,
+		PlayerName:   *playerName,
+		Bot:          *bot,
+		Achievements: *achievements,
+		Settings:     *settings,
+		NoAudio:      *noAudio,
 	}
-	theGame.StartBackgroundMusic()
-	if theGame.playerSprite == nil || theGame.Lost {
-		playerImg, err := assets.GetPlayerYellowSprite()
+	if *hostAddr != "" || *joinAddr != "" {
+		cfg.Session, err = connect(cfg.Seed)
 		if err != nil {
-			log.Fatalf("failed to load player sprite: %v", err)
-		}
-		playerSprite := sprites.NewPlayerSprite(playerImg, 32, 32, []sprites.Animation{
-			{Name: "right", Frames: 12},
-			{Name: "left", Frames: 12},
-			{Name: "up", Frames: 12},
-			{Name: "down", Frames: 12},
-		})
-		theGame.playerSprite = playerSprite
-		theGame.Lost = false
-		theGame.CurrentLevel = 0
-	}
-
-	wallTile, err := assets.GetWallTileImage()
-	if err != nil {
-		return fmt.Errorf("failed to load wall tile image: %w", err)
-	}
-
-	floorTile, err := assets.GetFloorTileImage()
-	if err != nil {
-		return fmt.Errorf("failed to load floor tile image: %w", err)
-	}
-
-	theGame.bites = bites
-	theGame.eatenBites = []sprites.CharacterSprite{}
-	theGame.bgImage = nil
-	theGame.enemies = []sprites.CharacterSprite{}
-	theGame.mapTiles, err = assets.GetMapTiles(levels[theGame.CurrentLevel].Tiles)
-	if err != nil {
-		return fmt.Errorf("failed to load map tiles: %w", err)
-	}
-	theGame.placeNewBite()
-	for range levels[theGame.CurrentLevel].StartEnemies {
-		theGame.placeNewEnemy()
-	}
-	theGame.wallTile = wallTile
-	theGame.floorTile = floorTile
-	theGame.title = GameTitle{
-		Visible:      true,
-		Duration:     5 * time.Second,
-		StartTime:    time.Now(),
-		WordsVisible: 0,
-		ShakeX:       0,
-		ShakeY:       0,
-		Text:         "8 BITES TO WIN!",
-	}
-	theGame.Ended = false
-
-	theGame.playerSprite.X, theGame.playerSprite.Y = theGame.GetRandomFloorPosition(64)
-	//select random tile to spawn slime
-	return nil
-}
-
-func (g *Game) placeNewBite() {
-	g.currentBite = g.bites[rand.IntN(len(theGame.bites))]
-	// retry if bite is already eaten, according to level reoccurrance settings
-	for range levels[g.CurrentLevel].ReoccurranceRetry {
-		if !g.hasBiteBeenEaten(g.currentBite) {
-			break
+			log.Fatalf("failed to set up network game: %v", err)
 		}
-		g.currentBite = g.bites[rand.IntN(len(theGame.bites))]
+		cfg.Seed = cfg.Session.Seed
+		cfg.Mode = game.GameMode(cfg.Session.Options)
 	}
-
-	g.currentBite.X, g.currentBite.Y = theGame.GetRandomFloorPosition(64)
-}
-
-func (g *Game) placeNewEnemy() {
-	slimeSprite := enemies[rand.IntN(len(enemies))]
-	slimeSprite.X, slimeSprite.Y = theGame.GetRandomFloorPosition(64)
-	g.enemies = append(g.enemies, *slimeSprite)
-}
-
-func (g *Game) StartBackgroundMusic() {
-	bgMusic, err := assets.GetBackgroundMusic(levels[g.CurrentLevel].Soundtrack)
+	g, err := game.NewGame(cfg)
 	if err != nil {
-		log.Fatalf("failed to load background music: %v", err)
-	}
-	fmt.Println("Starting background music")
-	theGame.MusicPlayer = bgMusic
-	go bgMusic.Play()
-}
-
-func (g *Game) drawMap(screen *ebiten.Image) {
-	for y := range mapHeight {
-		for x := range mapWidth {
-			tile := g.mapTiles[y][x]
-			op := &ebiten.DrawImageOptions{}
-			op.GeoM.Translate(float64(x*32), float64(y*32))
-			tileImg := g.floorTile
-			if tile == 1 {
-				tileImg = g.wallTile
-			}
-			screen.DrawImage(tileImg, op)
-		}
-	}
-}
-
-// Draw renders the game state to the screen.
-func (g *Game) Draw(screen *ebiten.Image) {
-	g.drawMap(screen)
-
-	// --- Draw Bites ---
-	biteOp := &ebiten.DrawImageOptions{}
-	biteOp.GeoM.Translate(float64(g.currentBite.X), float64(g.currentBite.Y))
-	biteImg := g.currentBite.GetCurrentImage()
-	screen.DrawImage(biteImg, biteOp)
-
-	// --- Draw Player ---
-	playerOp := &ebiten.DrawImageOptions{}
-	playerOp.GeoM.Translate(float64(g.playerSprite.X), float64(g.playerSprite.Y))
-	playerImg := g.playerSprite.GetCurrentImage()
-	screen.DrawImage(playerImg, playerOp)
-
-	for _, enemy := range g.enemies {
-		slimeOp := &ebiten.DrawImageOptions{}
-		slimeOp.GeoM.Translate(float64(enemy.X), float64(enemy.Y))
-		slimeImg := enemy.GetCurrentImage()
-		screen.DrawImage(slimeImg, slimeOp)
-	}
-
-	for i, bite := range g.eatenBites {
-		eatenBiteOp := &ebiten.DrawImageOptions{}
-		eatenBiteOp.GeoM.Translate(float64(i)*32, 0)
-		eatenBiteImg := bite.GetFirstImage()
-		screen.DrawImage(eatenBiteImg, eatenBiteOp)
-	}
-
-	g.drawScore(screen)
-
-	// Draw title on new level
-	if g.title.Visible {
-		g.drawTitle(screen)
-	}
-}
-
-func (g *Game) drawScore(screen *ebiten.Image) {
-	t := text.GoTextFace{
-		Source: font,
-		Size:   16,
-	}
-
-	numToDraw := g.playerSprite.Points
-	if g.playerSprite.Points > g.playerSprite.LastPoints {
-		numToDraw = g.playerSprite.LastPoints
-		g.playerSprite.LastPoints += (g.playerSprite.Points-g.playerSprite.LastPoints)/10 + 1
-	}
-	// draw score with 10 leading zeros
-	pointsText := fmt.Sprintf("Score: %010d", numToDraw)
-	op := &text.DrawOptions{}
-	op.GeoM.Translate(32, float64(screenHeight-24))
-	op.ColorScale.ScaleWithColor(color.White)
-	text.Draw(screen, pointsText, &t, op)
-}
-
-func (g *Game) drawTitle(screen *ebiten.Image) {
-
-	t := text.GoTextFace{
-		Source: font,
-		Size:   24,
-	}
-
-	words := strings.Split(g.title.Text, " ")
-	tw, th := text.Measure(g.title.Text, &t, 0)
-	x, y := screenWidth/2-tw/2, screenHeight/2-th/2
-
-	//Draw white block around text
-	bgOp := &ebiten.DrawImageOptions{}
-	bgOp.GeoM.Translate(float64(x-10), float64(y-10))
-	bgRect := ebiten.NewImage(int(tw)+20, int(th)+20)
-	//half-transparent background
-	bgRect.Fill(color.RGBA{220, 220, 225, 0})
-	screen.DrawImage(bgRect, bgOp)
-
-	op := &text.DrawOptions{}
-	op.GeoM.Translate(x, y)
-	op.ColorScale.ScaleWithColor(color.Gray{})
-	if time.Since(g.title.LastShakeTime) > 50*time.Millisecond {
-		g.title.ShakeX = rand.IntN(6) - 3
-		g.title.ShakeY = rand.IntN(6) - 3
-		g.title.LastShakeTime = time.Now()
-	}
-	op.GeoM.Translate(float64(g.title.ShakeX), float64(g.title.ShakeY))
-	for i := 0; i < g.title.WordsVisible && i < len(words); i++ {
-		word := words[i]
-		wordWidth, _ := text.Measure(word+" ", &t, 0)
-		text.Draw(screen, word+" ", &t, op)
-		op.GeoM.Translate(float64(wordWidth), 0)
-	}
-	if time.Since(g.title.StartTime) > time.Second*time.Duration(g.title.WordsVisible) && g.title.WordsVisible < len(words) {
-		g.title.WordsVisible++
-	}
-	if time.Since(g.title.StartTime) > g.title.Duration {
-		g.title.Visible = false
-	}
-}
-
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return screenWidth, screenHeight
-}
-
-func main() {
-	if theGame == nil {
-		log.Fatal("Game initialization failed. Check the init function.")
+		log.Fatal(err)
 	}
 
-	ebiten.SetWindowSize(screenWidth, screenHeight)
+	ebiten.SetWindowSize(game.ScreenWidth, game.ScreenHeight)
 	ebiten.SetWindowTitle("8bites")
-	if err := ebiten.RunGame(theGame); err != nil {
+	if err := ebiten.RunGame(g); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,192 @@
+// Package env exposes the game as a reinforcement learning environment in the
+// style of OpenAI Gym. Commands and results are exchanged as line-delimited
+// JSON, so agents can be written in any language:
+//
+//	{"cmd": "reset", "seed": 1, "level": 0}
+//	{"cmd": "step", "action": "left"}
+//	{"cmd": "close"}
+//
+// Every command but close is answered with a Result. An episode is a single
+// level, it is done once the level is won or lost.
+package env
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/NautiluX/8bites/pkg/game"
+)
+
+const (
+	defaultFrameSkip    = 4
+	defaultDeathPenalty = 1000
+	// maxLineSize limits the size of a command.
+	maxLineSize = 1 << 20
+)
+
+// Command is sent by the agent.
+type Command struct {
+	Cmd string `json:"cmd"`
+	// Seed, Level, FrameSkip and DeathPenalty configure the episode started by reset.
+	Seed  uint64 `json:"seed"`
+	Level int    `json:"level"`
+	// FrameSkip is the number of ticks an action is repeated, 4 by default.
+	FrameSkip int `json:"frame_skip"`
+	// DeathPenalty is subtracted from the reward when the player is caught, 1000 by default.
+	DeathPenalty *int `json:"death_penalty"`
+	// Action is one of none, up, down, left or right.
+	Action string `json:"action"`
+}
+
+// Result is sent back for every command.
+type Result struct {
+	Observation *game.Observation `json:"observation,omitempty"`
+	Reward      int               `json:"reward"`
+	Done        bool              `json:"done"`
+	Info        Info              `json:"info"`
+	Error       string            `json:"error,omitempty"`
+}
+
+type Info struct {
+	Points     int    `json:"points"`
+	Won        bool   `json:"won"`
+	DeathCause string `json:"death_cause,omitempty"`
+	Ticks      int    `json:"ticks"`
+}
+
+var actions = map[string]game.Input{
+	"none":  0,
+	"":      0,
+	"up":    game.InputUp,
+	"down":  game.InputDown,
+	"left":  game.InputLeft,
+	"right": game.InputRight,
+}
+
+// Env runs episodes of single player games.
+type Env struct {
+	// Assets are used by all episodes. They are loaded on the first reset if they are nil.
+	Assets *game.Assets
+
+	game         *game.Game
+	frameSkip    int
+	deathPenalty int
+}
+
+// Serve reads commands from r and writes the results to w, until the close
+// command is received or r ends.
+func (e *Env) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	encoder := json.NewEncoder(w)
+	for scanner.Scan() {
+		var cmd Command
+		err := json.Unmarshal(scanner.Bytes(), &cmd)
+		var result Result
+		switch {
+		case err != nil:
+			result.Error = fmt.Sprintf("invalid command: %v", err)
+		case cmd.Cmd == "close":
+			return nil
+		default:
+			result, err = e.Handle(cmd)
+			if err != nil {
+				result.Error = err.Error()
+			}
+		}
+		err = encoder.Encode(result)
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Handle runs a single reset or step command.
+func (e *Env) Handle(cmd Command) (Result, error) {
+	switch cmd.Cmd {
+	case "reset":
+		return e.reset(cmd)
+	case "step":
+		return e.step(cmd)
+	}
+	return Result{}, fmt.Errorf("unknown command %q", cmd.Cmd)
+}
+
+func (e *Env) reset(cmd Command) (Result, error) {
+	if e.Assets == nil {
+		a, err := game.LoadAssets()
+		if err != nil {
+			return Result{}, err
+		}
+		e.Assets = a
+	}
+	g, err := game.NewGame(game.Config{
+		Mode:       game.ModeSingle,
+		Assets:     e.Assets,
+		Seed:       cmd.Seed,
+		Headless:   true,
+		StartLevel: cmd.Level,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	e.game = g
+	e.frameSkip = cmd.FrameSkip
+	if e.frameSkip <= 0 {
+		e.frameSkip = defaultFrameSkip
+	}
+	e.deathPenalty = defaultDeathPenalty
+	if cmd.DeathPenalty != nil {
+		e.deathPenalty = *cmd.DeathPenalty
+	}
+	return e.result(0), nil
+}
+
+func (e *Env) step(cmd Command) (Result, error) {
+	if e.game == nil {
+		return Result{}, fmt.Errorf("reset has to be called before step")
+	}
+	if e.game.Ended {
+		return Result{}, fmt.Errorf("episode is done, call reset")
+	}
+	input, ok := actions[cmd.Action]
+	if !ok {
+		return Result{}, fmt.Errorf("unknown action %q", cmd.Action)
+	}
+
+	player := e.game.Players()[0]
+	pointsBefore := player.Points
+	for range e.frameSkip {
+		err := e.game.Tick([]game.Input{input})
+		if err != nil {
+			return Result{}, err
+		}
+		if e.game.Ended {
+			break
+		}
+	}
+	reward := player.Points - pointsBefore
+	if e.game.Lost {
+		reward -= e.deathPenalty
+	}
+	return e.result(reward), nil
+}
+
+func (e *Env) result(reward int) Result {
+	player := e.game.Players()[0]
+	observation := e.game.Observe()
+	return Result{
+		Observation: &observation,
+		Reward:      reward,
+		Done:        e.game.Ended,
+		Info: Info{
+			Points:     player.Points,
+			Won:        e.game.Ended && !e.game.Lost,
+			DeathCause: player.DeathCause,
+			Ticks:      e.game.LevelTicks(),
+		},
+	}
+}
@@ -0,0 +1,270 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/NautiluX/8bites/assets"
+	"github.com/NautiluX/8bites/pkg/atomicfile"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const toastDuration = 3 * time.Second
+
+// AchievementTrigger is the moment in the game an achievement is checked.
+type AchievementTrigger string
+
+const (
+	TriggerBiteEaten      AchievementTrigger = "bite_eaten"
+	TriggerLevelCompleted AchievementTrigger = "level_completed"
+	TriggerGameCompleted  AchievementTrigger = "game_completed"
+)
+
+// Achievement is unlocked when its trigger happens and all of its conditions
+// are met. Conditions that are unset are ignored.
+type Achievement struct {
+	Id          string
+	Name        string
+	Description string
+	On          AchievementTrigger
+	// Level is the name of the level the achievement has to be earned in.
+	Level string
+	// MinPoints is the score the player needs.
+	MinPoints int
+	// MinEaten is the number of different bites the player's team ate in the level.
+	MinEaten int
+	// MinEnemies is the number of slimes on the field.
+	MinEnemies int
+	// NoDuplicates requires that the team ate no bite twice in the level.
+	NoDuplicates bool
+	// NoLosses requires that no game was lost since the game was started.
+	NoLosses bool
+}
+
+func loadAchievements() ([]Achievement, error) {
+	data, err := assets.GetAchievementConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load achievement config: %w", err)
+	}
+	var a []Achievement
+	err = json.Unmarshal(data, &a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse achievement config: %w", err)
+	}
+	ids := map[string]bool{}
+	for _, achievement := range a {
+		if achievement.Id == "" || ids[achievement.Id] {
+			return nil, fmt.Errorf("achievement %q needs a unique Id", achievement.Name)
+		}
+		ids[achievement.Id] = true
+		switch achievement.On {
+		case TriggerBiteEaten, TriggerLevelCompleted, TriggerGameCompleted:
+		default:
+			return nil, fmt.Errorf("achievement %s has unknown trigger %q", achievement.Id, achievement.On)
+		}
+	}
+	return a, nil
+}
+
+// achievementTracker keeps the achievements unlocked by the local player.
+type achievementTracker struct {
+	// path is the file the unlocks are stored in, they aren't stored if it is empty.
+	path     string
+	unlocked map[string]time.Time
+
+	toasts     []Achievement
+	toastStart time.Time
+	screen     bool
+}
+
+// loadAchievementTracker reads the unlocked achievements from the file at path, if it exists.
+func loadAchievementTracker(path string) (*achievementTracker, error) {
+	t := &achievementTracker{path: path, unlocked: map[string]time.Time{}}
+	if path == "" {
+		return t, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &t.unlocked)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *achievementTracker) save() error {
+	if t.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(t.unlocked, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(t.path), 0o755)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(t.path, data, 0o644)
+}
+
+func (t *achievementTracker) unlock(a Achievement) {
+	t.unlocked[a.Id] = time.Now()
+	t.toasts = append(t.toasts, a)
+	err := t.save()
+	if err != nil {
+		log.Printf("failed to save achievements: %v", err)
+	}
+}
+
+// earnsAchievements reports whether p is played by a human at this computer.
+func (g *Game) earnsAchievements(p *PlayerSlot) bool {
+	if g.achievements == nil || p.Bot {
+		return false
+	}
+	return g.session == nil || p.Number-1 == g.session.Player
+}
+
+// checkAchievements unlocks the achievements of the trigger that p earned.
+// level is the index of the level the trigger happened in.
+func (g *Game) checkAchievements(trigger AchievementTrigger, p *PlayerSlot, level int) {
+	if !g.earnsAchievements(p) {
+		return
+	}
+	for _, a := range g.assets.Achievements {
+		if _, ok := g.achievements.unlocked[a.Id]; ok || a.On != trigger {
+			continue
+		}
+		if g.achievementMet(a, p, level) {
+			g.achievements.unlock(a)
+		}
+	}
+}
+
+func (g *Game) achievementMet(a Achievement, p *PlayerSlot, level int) bool {
+	switch {
+	case a.Level != "" && a.Level != g.levels[level].Name:
+		return false
+	case p.Points < a.MinPoints:
+		return false
+	case len(p.Team.eatenBites) < a.MinEaten:
+		return false
+	case len(g.enemies) < a.MinEnemies:
+		return false
+	case a.NoDuplicates && p.Team.duplicates > 0:
+		return false
+	case a.NoLosses && g.losses > 0:
+		return false
+	}
+	return true
+}
+
+// handleAchievementScreen opens and closes the list of achievements with Tab.
+// It returns true while the list is shown.
+func (g *Game) handleAchievementScreen() bool {
+	if g.achievements == nil {
+		return false
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		g.achievements.screen = !g.achievements.screen
+		g.playSfx(SfxMenu)
+	}
+	return g.achievements.screen
+}
+
+// drawAchievements shows the toasts of new unlocks and the achievement screen.
+func (g *Game) drawAchievements(screen *ebiten.Image) {
+	if g.achievements == nil {
+		return
+	}
+	if g.achievements.screen {
+		g.drawAchievementScreen(screen)
+	}
+	g.drawToast(screen)
+}
+
+func (g *Game) drawToast(screen *ebiten.Image) {
+	a := g.achievements
+	if len(a.toasts) == 0 {
+		return
+	}
+	if a.toastStart.IsZero() {
+		a.toastStart = time.Now()
+	}
+	if time.Since(a.toastStart) > toastDuration {
+		a.toasts = a.toasts[1:]
+		a.toastStart = time.Time{}
+		return
+	}
+
+	t := text.GoTextFace{
+		Source: g.assets.font,
+		Size:   16,
+	}
+	message := "UNLOCKED: " + a.toasts[0].Name
+	tw, th := text.Measure(message, &t, 0)
+	x, y := ScreenWidth/2-tw/2, 48.0
+	vector.DrawFilledRect(screen, float32(x-8), float32(y-8), float32(tw+16), float32(th+16), color.RGBA{40, 40, 60, 220}, false)
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(x, y)
+	op.ColorScale.ScaleWithColor(color.RGBA{255, 220, 80, 255})
+	text.Draw(screen, message, &t, op)
+}
+
+func (g *Game) drawAchievementScreen(screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, 0, 0, ScreenWidth, ScreenHeight, color.RGBA{0, 0, 0, 220}, false)
+	title := text.GoTextFace{
+		Source: g.assets.font,
+		Size:   24,
+	}
+	name := text.GoTextFace{
+		Source: g.assets.font,
+		Size:   16,
+	}
+	description := text.GoTextFace{
+		Source: g.assets.font,
+		Size:   10,
+	}
+
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(32, 32)
+	op.ColorScale.ScaleWithColor(color.White)
+	text.Draw(screen, fmt.Sprintf("ACHIEVEMENTS %d/%d", len(g.achievements.unlocked), len(g.assets.Achievements)), &title, op)
+
+	for i, a := range g.assets.Achievements {
+		y := 88 + float64(i)*44
+		c := color.RGBA{120, 120, 120, 255}
+		mark := "[ ] "
+		if _, ok := g.achievements.unlocked[a.Id]; ok {
+			c = color.RGBA{255, 220, 80, 255}
+			mark = "[X] "
+		}
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(32, y)
+		op.ColorScale.ScaleWithColor(c)
+		text.Draw(screen, mark+a.Name, &name, op)
+		op = &text.DrawOptions{}
+		op.GeoM.Translate(96, y+20)
+		op.ColorScale.ScaleWithColor(c)
+		text.Draw(screen, a.Description, &description, op)
+	}
+
+	op = &text.DrawOptions{}
+	op.GeoM.Translate(32, ScreenHeight-32)
+	op.ColorScale.ScaleWithColor(color.White)
+	text.Draw(screen, "[TAB] BACK", &name, op)
+}
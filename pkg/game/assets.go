@@ -0,0 +1,193 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/NautiluX/8bites/assets"
+	"github.com/NautiluX/8bites/pkg/sprites"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/examples/resources/fonts"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// Assets are the loaded configs, sprites and fonts. They can be shared by
+// several games, which copy what they change, e.g. when mods are reloaded.
+type Assets struct {
+	// Levels are the levels of the game, in the order they are played.
+	Levels       []Level
+	Achievements []Achievement
+
+	font      *text.GoTextFaceSource
+	bites     []*sprites.CharacterSprite
+	enemies   []*sprites.CharacterSprite
+	wallTile  *ebiten.Image
+	floorTile *ebiten.Image
+	// playerImage and playerAnimations make up the sprites of the players.
+	playerImage      *ebiten.Image
+	playerAnimations []sprites.Animation
+	// maps holds the tiles of the maps of the levels by name.
+	maps map[string][mapHeight][mapWidth]int
+	// biteNames maps the sprite ids of the bites to their names, as used in goals.
+	biteNames map[sprites.SpriteId]string
+	// spriteFiles maps sprite sheets to the id of the sprites using them, so the
+	// images can be swapped when a sheet changes.
+	spriteFiles map[string]sprites.SpriteId
+}
+
+// biteKinds are all bites of the game, with the names goals refer to them by
+// and their food group, for GoalFoodGroup.
+var biteKinds = []struct {
+	name  string
+	id    sprites.SpriteId
+	group string
+}{
+	{"cheese", sprites.SpriteIdCheese, "savory"},
+	{"pizza", sprites.SpriteIdPizza, "savory"},
+	{"donut", sprites.SpriteIdDonut, "sweet"},
+	{"sushi", sprites.SpriteIdSushi, "savory"},
+	{"orange", sprites.SpriteIdOrange, "fruit"},
+	{"avocado", sprites.SpriteIdAvocado, "fruit"},
+	{"apple", sprites.SpriteIdApple, "fruit"},
+	{"banana", sprites.SpriteIdBanana, "fruit"},
+}
+
+// LoadAssets loads the assets of the game, from the mod directory if it is set.
+func LoadAssets() (*Assets, error) {
+	a := &Assets{
+		biteNames: map[sprites.SpriteId]string{},
+		spriteFiles: map[string]sprites.SpriteId{
+			"player/yellow.png": sprites.SpriteIdPlayer,
+			"npc/slime.png":     sprites.SpriteIdSlime,
+		},
+	}
+	var err error
+	a.Levels, err = loadLevels()
+	if err != nil {
+		return nil, err
+	}
+
+	a.maps, err = loadMaps(a.Levels)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Achievements, err = loadAchievements()
+	if err != nil {
+		return nil, err
+	}
+
+	a.wallTile, err = assets.GetWallTileImage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load wall tile image: %w", err)
+	}
+	a.floorTile, err = assets.GetFloorTileImage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load floor tile image: %w", err)
+	}
+
+	a.playerImage, err = assets.GetPlayerYellowSprite()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load player sprite: %w", err)
+	}
+	a.playerAnimations, err = assets.GetAnimations("player/yellow.png")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load player animations: %w", err)
+	}
+
+	a.font, err = text.NewGoTextFaceSource(bytes.NewReader(fonts.PressStart2P_ttf))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font: %w", err)
+	}
+
+	for _, b := range biteKinds {
+		sprite, err := a.loadSprite(b.name, b.id)
+		if err != nil {
+			return nil, err
+		}
+		a.bites = append(a.bites, sprite)
+	}
+
+	slimeImg, err := assets.GetSlimeSprite()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load slime sprite: %w", err)
+	}
+	slimeAnimations, err := assets.GetAnimations("npc/slime.png")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load slime animations: %w", err)
+	}
+	slimeSprite := sprites.NewCharacterSprite(slimeImg, 32, 32, slimeAnimations, sprites.SpriteIdSlime)
+
+	a.enemies = []*sprites.CharacterSprite{slimeSprite}
+	return a, nil
+}
+
+func loadLevels() ([]Level, error) {
+	data, err := assets.GetLevelConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load level config: %w", err)
+	}
+	var l []Level
+	err = json.Unmarshal(data, &l)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse level config: %w", err)
+	}
+	if len(l) == 0 {
+		return nil, fmt.Errorf("level config contains no levels")
+	}
+	for _, level := range l {
+		err := level.validate()
+		if err != nil {
+			return nil, fmt.Errorf("invalid level %s: %w", level.Name, err)
+		}
+	}
+	return l, nil
+}
+
+// loadMaps loads the maps used by the levels.
+func loadMaps(levels []Level) (map[string][mapHeight][mapWidth]int, error) {
+	m := map[string][mapHeight][mapWidth]int{}
+	for _, level := range levels {
+		tiles, ok := m[level.Tiles]
+		if !ok {
+			var err error
+			tiles, err = assets.GetMapTiles(level.Tiles)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load map %s: %w", level.Tiles, err)
+			}
+			m[level.Tiles] = tiles
+		}
+		err := level.checkMap(tiles)
+		if err != nil {
+			return nil, fmt.Errorf("invalid level %s: %w", level.Name, err)
+		}
+	}
+	return m, nil
+}
+
+// loadSprite loads the sprite of a bite.
+func (a *Assets) loadSprite(spriteName string, spriteId sprites.SpriteId) (*sprites.CharacterSprite, error) {
+	a.spriteFiles["items/"+spriteName+".png"] = spriteId
+	a.biteNames[spriteId] = spriteName
+	img, err := assets.GetItem(spriteName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s image: %w", spriteName, err)
+	}
+	animations, err := assets.GetAnimations("items/" + spriteName + ".png")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s animations: %w", spriteName, err)
+	}
+	return sprites.NewCharacterSprite(img, 32, 32, animations, spriteId), nil
+}
+
+// cloneSprites copies the sprites, so their images can be swapped without
+// affecting other games.
+func cloneSprites(s []*sprites.CharacterSprite) []*sprites.CharacterSprite {
+	clones := make([]*sprites.CharacterSprite, len(s))
+	for i, sprite := range s {
+		clone := *sprite
+		clones[i] = &clone
+	}
+	return clones
+}
@@ -0,0 +1,72 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/NautiluX/8bites/assets"
+	"github.com/NautiluX/8bites/pkg/sound"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// newAudio creates the audio manager. Without a sound device, or if the
+// settings can't be loaded, the game stays silent.
+func (g *Game) newAudio(cfg Config) *sound.Manager {
+	var backend sound.Backend = sound.NullBackend{}
+	if !cfg.NoAudio {
+		device, err := sound.NewDeviceBackend()
+		if err != nil {
+			g.reportAudioError(err)
+		} else {
+			backend = device
+		}
+	}
+	manager, err := sound.NewManager(backend, assets.GetSound, cfg.Settings)
+	if err != nil {
+		g.reportAudioError(err)
+		return nil
+	}
+	return manager
+}
+
+// reportAudioError logs the error, unless it was logged before.
+func (g *Game) reportAudioError(err error) {
+	if g.audioErrors == nil {
+		g.audioErrors = map[string]bool{}
+	}
+	if g.audioErrors[err.Error()] {
+		return
+	}
+	g.audioErrors[err.Error()] = true
+	log.Printf("%v, continuing without it", err)
+}
+
+// updateAudio fades the music, ducking it while the title is shown, and
+// handles the volume keys: M mutes, minus and plus change the master volume.
+func (g *Game) updateAudio() {
+	if g.audio == nil {
+		return
+	}
+	soundtrack := g.soundtrack()
+	if g.title.Visible {
+		g.audio.Duck(soundtrack.Duck, seconds(soundtrack.DuckFade))
+	} else {
+		g.audio.Duck(1, seconds(soundtrack.DuckFade))
+	}
+	err := g.audio.Update(time.Second / time.Duration(ebiten.TPS()))
+	if err != nil {
+		g.reportAudioError(fmt.Errorf("sound device failed: %w", err))
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		g.audio.ToggleMute()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyMinus) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadSubtract) {
+		g.audio.ChangeVolume(-1)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEqual) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadAdd) {
+		g.audio.ChangeVolume(1)
+	}
+}
@@ -0,0 +1,142 @@
+package game
+
+// botDangerDistance is how many tiles the bot keeps away from enemies, if
+// the map allows it.
+const botDangerDistance = 1
+
+type tile struct {
+	x, y int
+}
+
+var botDirections = []struct {
+	dx, dy int
+	input  Input
+}{
+	{0, -1, InputUp},
+	{0, 1, InputDown},
+	{-1, 0, InputLeft},
+	{1, 0, InputRight},
+}
+
+// BotInput returns the input of a built-in AI for the given player. The bot
+// takes the shortest way to the closest bite, avoiding the tiles around
+// enemies. Once a level is won it continues with the next one.
+func (g *Game) BotInput(player int) Input {
+	p := g.players[player]
+	if g.Ended {
+		if g.Finished() {
+			return 0
+		}
+		return InputRestart
+	}
+	if p.Dead {
+		return 0
+	}
+
+	start := g.botDecisionTile(p)
+	danger := g.dangerTiles()
+	targets := map[tile]bool{}
+	for _, bite := range g.activeBites {
+		targets[tile{(bite.X + 16) / 32, (bite.Y + 16) / 32}] = true
+	}
+
+	if input, ok := g.botPathInput(start, targets, danger); ok {
+		return input
+	}
+	// No safe way to a bite, so ignore the enemies and hope for the best.
+	if !danger[start] {
+		if input, ok := g.botPathInput(start, targets, nil); ok {
+			return input
+		}
+	}
+	return g.botFleeInput(start)
+}
+
+// botDecisionTile returns the tile where the player can change direction
+// next. Close to the middle of a tile the player can still turn there.
+func (g *Game) botDecisionTile(p *PlayerSlot) tile {
+	t := tile{(p.X + 16) / 32, (p.Y + 16) / 32}
+	if dx := sign(p.CurrentVx); abs(laneOffset(p.X)) > cornerTolerance && laneOffset(p.X)*dx > 0 {
+		t.x += dx
+	}
+	if dy := sign(p.CurrentVy); abs(laneOffset(p.Y)) > cornerTolerance && laneOffset(p.Y)*dy > 0 {
+		t.y += dy
+	}
+	return t
+}
+
+func (g *Game) isFloor(t tile) bool {
+	return t.x >= 0 && t.x < mapWidth && t.y >= 0 && t.y < mapHeight && g.mapTiles[t.y][t.x] == 0
+}
+
+// dangerTiles returns the tiles enemies occupy or can reach soon.
+func (g *Game) dangerTiles() map[tile]bool {
+	danger := map[tile]bool{}
+	for _, enemy := range g.enemies {
+		center := tile{(enemy.X + 16) / 32, (enemy.Y + 16) / 32}
+		for dy := -botDangerDistance; dy <= botDangerDistance; dy++ {
+			for dx := -botDangerDistance; dx <= botDangerDistance; dx++ {
+				if abs(dx)+abs(dy) <= botDangerDistance {
+					danger[tile{center.x + dx, center.y + dy}] = true
+				}
+			}
+		}
+	}
+	return danger
+}
+
+// botPathInput searches the shortest path to one of the targets with a
+// breadth first search, and returns the input for its first step.
+func (g *Game) botPathInput(start tile, targets, blocked map[tile]bool) (Input, bool) {
+	if targets[start] {
+		return 0, false
+	}
+	firstStep := map[tile]Input{start: 0}
+	queue := []tile{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dir := range botDirections {
+			next := tile{current.x + dir.dx, current.y + dir.dy}
+			if _, seen := firstStep[next]; seen || !g.isFloor(next) || blocked[next] {
+				continue
+			}
+			firstStep[next] = firstStep[current]
+			if current == start {
+				firstStep[next] = dir.input
+			}
+			if targets[next] {
+				return firstStep[next], true
+			}
+			queue = append(queue, next)
+		}
+	}
+	return 0, false
+}
+
+// botFleeInput moves to the neighbouring tile farthest away from all enemies.
+func (g *Game) botFleeInput(start tile) Input {
+	best, bestDistance := Input(0), -1
+	for _, dir := range botDirections {
+		next := tile{start.x + dir.dx, start.y + dir.dy}
+		if !g.isFloor(next) {
+			continue
+		}
+		distance := mapWidth + mapHeight
+		for _, enemy := range g.enemies {
+			d := abs(next.x-(enemy.X+16)/32) + abs(next.y-(enemy.Y+16)/32)
+			distance = min(distance, d)
+		}
+		if distance > bestDistance {
+			best, bestDistance = dir.input, distance
+		}
+	}
+	return best
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
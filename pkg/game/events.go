@@ -0,0 +1,189 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/NautiluX/8bites/pkg/sprites"
+)
+
+// Event is something that happened in the simulation. Events are published
+// after the rules changed the game state, so subscribers see the new state.
+type Event interface {
+	event()
+}
+
+// LevelStarted is published when a level starts, including restarts.
+type LevelStarted struct {
+	Level int
+}
+
+// BiteEaten is published when a player eats a bite the team hasn't eaten in the level yet.
+type BiteEaten struct {
+	Player *PlayerSlot
+	Bite   *sprites.CharacterSprite
+}
+
+// DuplicateEaten is published when a player eats a bite the team ate before.
+type DuplicateEaten struct {
+	Player *PlayerSlot
+	Bite   *sprites.CharacterSprite
+}
+
+// EnemySpawned is published when a slime is placed on the field.
+type EnemySpawned struct {
+	X, Y int
+	// AtLevelStart is set for the slimes placed when the level starts.
+	AtLevelStart bool
+}
+
+// PlayerDied is published when a player is caught by a slime.
+type PlayerDied struct {
+	Player *PlayerSlot
+}
+
+// LevelCompleted is published when a team reaches the goal of a level,
+// including the last one.
+type LevelCompleted struct {
+	Team  *Team
+	Level int
+}
+
+// GameCompleted is published when a team completes the last level.
+type GameCompleted struct {
+	Team  *Team
+	Level int
+}
+
+// GameOver is published when all players died.
+type GameOver struct{}
+
+func (LevelStarted) event()   {}
+func (BiteEaten) event()      {}
+func (DuplicateEaten) event() {}
+func (EnemySpawned) event()   {}
+func (PlayerDied) event()     {}
+func (LevelCompleted) event() {}
+func (GameCompleted) event()  {}
+func (GameOver) event()       {}
+
+// EventBus delivers published events to its subscribers, in the order they subscribed.
+type EventBus struct {
+	handlers []func(Event) error
+}
+
+// Subscribe calls handle for every event of type E published on the bus.
+func Subscribe[E Event](b *EventBus, handle func(E) error) {
+	b.handlers = append(b.handlers, func(e Event) error {
+		if e, ok := e.(E); ok {
+			return handle(e)
+		}
+		return nil
+	})
+}
+
+// Publish passes the event to all subscribers. It stops at the first one
+// that returns an error.
+func (b *EventBus) Publish(e Event) error {
+	for _, handle := range b.handlers {
+		err := handle(e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Events returns the bus the game publishes its events on.
+func (g *Game) Events() *EventBus {
+	return &g.events
+}
+
+// subscribe connects the systems of the game to its events.
+func (g *Game) subscribe() {
+	g.subscribeTitle()
+	g.subscribeReplay()
+	if !g.headless {
+		g.subscribeAudio()
+	}
+	if g.achievements != nil {
+		g.subscribeAchievements()
+	}
+}
+
+// subscribeTitle shows the outcome of a level.
+func (g *Game) subscribeTitle() {
+	show := func(text string) {
+		g.title.Visible = true
+		g.title.StartTime = g.now()
+		g.title.WordsVisible = 0
+		g.title.Text = text
+	}
+	Subscribe(&g.events, func(e LevelCompleted) error {
+		if e.Level+1 >= len(g.levels) {
+			// GameCompleted follows.
+			return nil
+		}
+		if g.Mode == ModeVersus {
+			show(fmt.Sprintf("P%d WINS! HIT [SPACE]", e.Team.players[0].Number))
+			return nil
+		}
+		show("YOU WIN! HIT [SPACE]")
+		return nil
+	})
+	Subscribe(&g.events, func(e GameCompleted) error {
+		if g.Mode == ModeVersus {
+			show(fmt.Sprintf("THE END - P%d WINS!", e.Team.players[0].Number))
+			return nil
+		}
+		show("THE END - GZ!")
+		return nil
+	})
+	Subscribe(&g.events, func(GameOver) error {
+		show("GAME OVER! HIT [SPACE]")
+		return nil
+	})
+}
+
+// subscribeReplay keeps the replay of the last finished game.
+func (g *Game) subscribeReplay() {
+	save := func() {
+		replay := g.replay
+		g.lastReplay = &replay
+	}
+	Subscribe(&g.events, func(GameCompleted) error {
+		save()
+		return nil
+	})
+	Subscribe(&g.events, func(GameOver) error {
+		save()
+		return nil
+	})
+}
+
+// subscribeAchievements checks the achievements triggered by the events.
+func (g *Game) subscribeAchievements() {
+	Subscribe(&g.events, func(e BiteEaten) error {
+		g.checkAchievements(TriggerBiteEaten, e.Player, g.CurrentLevel)
+		return nil
+	})
+	Subscribe(&g.events, func(e DuplicateEaten) error {
+		g.checkAchievements(TriggerBiteEaten, e.Player, g.CurrentLevel)
+		return nil
+	})
+	Subscribe(&g.events, func(e LevelCompleted) error {
+		for _, p := range e.Team.players {
+			g.checkAchievements(TriggerLevelCompleted, p, e.Level)
+		}
+		return nil
+	})
+	Subscribe(&g.events, func(e GameCompleted) error {
+		for _, p := range e.Team.players {
+			g.checkAchievements(TriggerGameCompleted, p, e.Level)
+		}
+		return nil
+	})
+	Subscribe(&g.events, func(GameOver) error {
+		g.losses++
+		return nil
+	})
+}
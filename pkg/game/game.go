@@ -0,0 +1,972 @@
+package game
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NautiluX/8bites/assets"
+	"github.com/NautiluX/8bites/pkg/netplay"
+	"github.com/NautiluX/8bites/pkg/sound"
+	"github.com/NautiluX/8bites/pkg/sprites"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	ScreenWidth  = 640
+	ScreenHeight = 480
+	mapWidth     = 640 / 32
+	mapHeight    = 480 / 32
+	playerSpeed  = 2
+
+	biteBlinkDuration = 2 * time.Second
+)
+
+type Level struct {
+	Name              string
+	Tiles             string
+	Soundtrack        Soundtrack
+	ReoccurranceRetry int
+	StartEnemies      int
+	// ActiveBites is how many bites are on the field at once, 1 if unset.
+	ActiveBites int
+	// BiteLifetime is how many seconds a bite stays on the field before it
+	// vanishes. Bites stay until they are eaten if unset.
+	BiteLifetime int
+	Goal         Goal
+	// Sfx overrides the sound effects of events in the level, see defaultSfx.
+	Sfx map[string]SoundEffect
+}
+
+func (l Level) maxBites() int {
+	if l.ActiveBites <= 0 {
+		return 1
+	}
+	return l.ActiveBites
+}
+
+const (
+	// maxActiveBites and maxBiteLifetime bound the bite settings of levels.
+	maxActiveBites  = 32
+	maxBiteLifetime = 60 * 60
+	// playerClearance is how many floor tiles can be too close to the
+	// players to place anything on, 3x3 tiles around each of two players.
+	playerClearance = 2 * 3 * 3
+)
+
+func (l Level) validate() error {
+	if l.ActiveBites < 0 || l.ActiveBites > maxActiveBites {
+		return fmt.Errorf("ActiveBites must be from 0 to %d", maxActiveBites)
+	}
+	if l.BiteLifetime < 0 || l.BiteLifetime > maxBiteLifetime {
+		return fmt.Errorf("BiteLifetime must be from 0 to %d seconds", maxBiteLifetime)
+	}
+	err := l.Goal.withDefaults().validate()
+	if err != nil {
+		return fmt.Errorf("invalid goal: %w", err)
+	}
+	return nil
+}
+
+// checkMap reports whether the map has room for the bites of the level, next
+// to the players.
+func (l Level) checkMap(tiles [mapHeight][mapWidth]int) error {
+	floor := 0
+	for _, row := range tiles {
+		for _, tile := range row {
+			if tile == 0 {
+				floor++
+			}
+		}
+	}
+	if floor < l.maxBites()+playerClearance {
+		return fmt.Errorf("map %s has %d floor tiles, too few for %d bites", l.Tiles, floor, l.maxBites())
+	}
+	return nil
+}
+
+// Bite is a bite on the field.
+type Bite struct {
+	sprites.CharacterSprite
+	// TicksLeft counts down to the tick the bite vanishes, 0 means never.
+	TicksLeft int
+}
+
+// Visible reports whether the bite is drawn, it blinks before it vanishes.
+func (b *Bite) Visible() bool {
+	blinkTicks := int(biteBlinkDuration.Seconds() * float64(ebiten.TPS()))
+	if b.TicksLeft == 0 || b.TicksLeft > blinkTicks {
+		return true
+	}
+	return b.TicksLeft/(ebiten.TPS()/8)%2 == 0
+}
+
+type Game struct {
+	// bgImage holds the rendered tiles of the map, it is nil until they are drawn.
+	bgImage *ebiten.Image
+	Mode    GameMode
+	players []*PlayerSlot
+	teams   []*Team
+	enemies []*sprites.CharacterSprite
+	// bites and enemyTemplates are the sprites new bites and enemies are
+	// copied from. They belong to the game, so mods can be reloaded.
+	bites          []*sprites.CharacterSprite
+	enemyTemplates []*sprites.CharacterSprite
+	activeBites    []*Bite
+	// enemyIndex and biteIndex track where the enemies and the bites on the
+	// field are, so collisions are found without testing all of them.
+	enemyIndex *sprites.Index
+	biteIndex  *sprites.Index
+	mapTiles   [mapHeight][mapWidth]int
+	// maps holds the tiles of the maps of the levels by name.
+	maps      map[string][mapHeight][mapWidth]int
+	wallTile  *ebiten.Image
+	floorTile *ebiten.Image
+	// playerImage and playerAnimations make up the sprites of new players.
+	playerImage      *ebiten.Image
+	playerAnimations []sprites.Animation
+	title            GameTitle
+	// hud holds the rendered texts of the HUD.
+	hud          map[string]*hudText
+	Ended        bool
+	Lost         bool
+	Completed    bool
+	CurrentLevel int
+
+	assets *Assets
+	levels []Level
+
+	// rng is the only source of randomness of the simulation, so games with
+	// the same seed and inputs play out the same.
+	rng *rand.Rand
+	// effects randomizes what doesn't affect the simulation, like the shaking title.
+	effects    *rand.Rand
+	clock      func() time.Time
+	nextSeed   uint64
+	bot        bool
+	startLevel int
+	levelTicks int
+	session    *netplay.Session
+	headless   bool
+	// audio is nil if the game is headless.
+	audio *sound.Manager
+	// audioErrors holds the audio errors that were reported, so they are only logged once.
+	audioErrors map[string]bool
+
+	replay     Replay
+	lastReplay *Replay
+
+	leaderboardURL string
+	playerName     string
+	submitStatus   string
+	submitResult   chan string
+
+	watcher         *assets.Watcher
+	lastReloadCheck time.Time
+	// pendingReloads are the changed files that weren't applied yet.
+	pendingReloads []string
+
+	events EventBus
+	// achievements is nil if the game is headless.
+	achievements *achievementTracker
+	// losses counts the games lost since the game was created.
+	losses int
+}
+
+type GameTitle struct {
+	Visible       bool
+	Duration      time.Duration
+	StartTime     time.Time
+	LastShakeTime time.Time
+	WordsVisible  int
+	ShakeX        int
+	ShakeY        int
+	Text          string
+}
+
+// Config defines how a game is played.
+type Config struct {
+	Mode GameMode
+	// Assets are shared with other games. The game loads its own if they are nil.
+	Assets *Assets
+	// Session connects the game to another peer for a network game.
+	Session *netplay.Session
+	// Seed initializes the randomness of the simulation.
+	Seed uint64
+	// Headless games play no audio, so they can be simulated without a sound device.
+	Headless bool
+	// Leaderboard is the URL of the leaderboard server scores are submitted to.
+	// Submitting is disabled if it is empty.
+	Leaderboard string
+	PlayerName  string
+	// Bot lets the built-in AI play the first player.
+	Bot bool
+	// StartLevel is the index of the level new games start with.
+	StartLevel int
+	// Achievements is the file unlocked achievements are stored in. They
+	// aren't stored if it is empty.
+	Achievements string
+	// Settings is the file the audio settings are stored in. They aren't
+	// stored if it is empty.
+	Settings string
+	// NoAudio plays no sound, without opening the sound device.
+	NoAudio bool
+	// Clock returns the time the titles are animated with, time.Now if nil.
+	// A fixed clock renders the same frames every time.
+	Clock func() time.Time
+}
+
+// NewGame creates a game and starts its first level.
+func NewGame(cfg Config) (*Game, error) {
+	a := cfg.Assets
+	if a == nil {
+		var err error
+		a, err = LoadAssets()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.StartLevel < 0 || cfg.StartLevel >= len(a.Levels) {
+		return nil, fmt.Errorf("there is no level %d", cfg.StartLevel)
+	}
+	g := &Game{
+		Mode:           cfg.Mode,
+		assets:         a,
+		levels:         slices.Clone(a.Levels),
+		bites:          cloneSprites(a.bites),
+		enemyTemplates: cloneSprites(a.enemies),
+		session:        cfg.Session,
+		headless:       cfg.Headless,
+		nextSeed:       cfg.Seed,
+		bot:            cfg.Bot,
+		effects:        rand.New(rand.NewPCG(cfg.Seed, 0)),
+		clock:          cfg.Clock,
+		hud:            map[string]*hudText{},
+		enemyIndex:     sprites.NewIndex(),
+		biteIndex:      sprites.NewIndex(),
+		startLevel:     cfg.StartLevel,
+
+		maps:             maps.Clone(a.maps),
+		wallTile:         a.wallTile,
+		floorTile:        a.floorTile,
+		playerImage:      a.playerImage,
+		playerAnimations: a.playerAnimations,
+
+		leaderboardURL: cfg.Leaderboard,
+		playerName:     cfg.PlayerName,
+	}
+	if !g.headless {
+		var err error
+		g.achievements, err = loadAchievementTracker(cfg.Achievements)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load achievements: %w", err)
+		}
+		g.audio = g.newAudio(cfg)
+	}
+	if g.session == nil && !g.headless {
+		// Mods are only reloaded in local games, as changes would bring networked games out of sync.
+		var err error
+		g.watcher, err = assets.NewWatcher()
+		if err != nil {
+			return nil, fmt.Errorf("failed to watch mod directory: %w", err)
+		}
+	}
+	g.subscribe()
+	err := g.Reset()
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// now returns the current time of the game's clock.
+func (g *Game) now() time.Time {
+	if g.clock == nil {
+		return time.Now()
+	}
+	return g.clock()
+}
+
+// maxPlacementAttempts limits how often a random position is drawn, so maps
+// without a free floor tile fail instead of hanging the game.
+const maxPlacementAttempts = 10000
+
+// GetRandomFloorPosition returns a random floor position at least minDistance
+// away from the players.
+func (g *Game) GetRandomFloorPosition(minDistance int) (int, int, error) {
+	return g.randomFloorPosition(minDistance, func(x, y int) bool { return true })
+}
+
+// randomFloorPosition returns a random floor position at least minDistance
+// away from the players, which is free according to the given function. All
+// conditions share one budget of attempts.
+func (g *Game) randomFloorPosition(minDistance int, free func(x, y int) bool) (int, int, error) {
+	for range maxPlacementAttempts {
+		x := g.rng.IntN(mapWidth) * 32
+		y := g.rng.IntN(mapHeight) * 32
+		if g.mapTiles[y/32][x/32] == 0 && g.isFarFromPlayers(x, y, minDistance) && free(x, y) {
+			return x, y, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("failed to find a free floor tile %d pixels away from the players", minDistance)
+}
+
+func (g *Game) isFarFromPlayers(x, y, minDistance int) bool {
+	for _, p := range g.players {
+		if math.Abs(float64(p.X-x)) < float64(minDistance) && math.Abs(float64(p.Y-y)) < float64(minDistance) {
+			return false
+		}
+	}
+	return true
+}
+
+// handleInputAndMovement processes keyboard input and updates the player's position,
+// enforcing screen boundaries.
+func (g *Game) handleInputAndMovement() {
+	for _, p := range g.alivePlayers() {
+		g.handlePlayerInput(p)
+	}
+
+	for _, slimeSprite := range g.enemies {
+		// Random movement for slime. 50% chance to change direction each update
+		updateMovement := g.rng.IntN(101)
+		if updateMovement > 75 && slimeSprite.CurrentVx == 0 && slimeSprite.X%32 == 0 && slimeSprite.Y%32 == 0 {
+			slimeSprite.CurrentVx = -1 + g.rng.IntN(3)
+			slimeSprite.CurrentVy = 0
+		}
+		if updateMovement < 25 && slimeSprite.CurrentVy == 0 && slimeSprite.Y%32 == 0 && slimeSprite.X%32 == 0 {
+			slimeSprite.CurrentVx = 0
+			slimeSprite.CurrentVy = -1 + g.rng.IntN(3)
+		}
+
+		if !g.checkWallCollision(slimeSprite) {
+			slimeSprite.Move(ScreenWidth, ScreenHeight)
+		}
+	}
+	for _, p := range g.alivePlayers() {
+		g.movePlayer(p, playerSpeed)
+	}
+}
+
+// handlePlayerInput remembers the direction the player wants to go, until
+// movePlayer can take it or another direction is pressed.
+func (g *Game) handlePlayerInput(p *PlayerSlot) {
+	directions := []struct {
+		input     Input
+		vx, vy    int
+		animation string
+	}{
+		{InputUp, 0, -playerSpeed, "up"},
+		{InputDown, 0, playerSpeed, "down"},
+		{InputLeft, -playerSpeed, 0, "left"},
+		{InputRight, playerSpeed, 0, "right"},
+	}
+	for _, dir := range directions {
+		if p.input&dir.input != 0 {
+			p.NextVx, p.NextVy = dir.vx, dir.vy
+			p.SetNextAnimation(dir.animation)
+		}
+	}
+}
+
+func (g *Game) checkGameEnd() error {
+	for _, team := range g.teams {
+		if !g.goalReached(team) {
+			continue
+		}
+		level := g.CurrentLevel
+		g.Ended = true
+		if level+1 >= len(g.levels) {
+			g.Completed = true
+		} else {
+			g.CurrentLevel++
+		}
+		err := g.events.Publish(LevelCompleted{Team: team, Level: level})
+		if err != nil {
+			return err
+		}
+		if g.Completed {
+			return g.events.Publish(GameCompleted{Team: team, Level: level})
+		}
+		return nil
+	}
+	for _, p := range g.alivePlayers() {
+		// The enemy that spawned first is blamed if several caught the player.
+		caught := -1
+		for enemy := range g.enemyIndex.Query(p.CollisionArea(p.Hurtbox(), maxReach(g.enemyTemplates))) {
+			if !p.HurtBy(enemy) {
+				continue
+			}
+			i := slices.Index(g.enemies, enemy)
+			if caught < 0 || i < caught {
+				caught = i
+			}
+		}
+		if caught < 0 {
+			continue
+		}
+		p.Dead = true
+		p.DeathCause = "slime from level start"
+		if caught >= g.levels[g.CurrentLevel].StartEnemies {
+			p.DeathCause = "slime from duplicate bite"
+		}
+		err := g.events.Publish(PlayerDied{Player: p})
+		if err != nil {
+			return err
+		}
+	}
+	if len(g.alivePlayers()) == 0 {
+		g.Ended = true
+		g.Lost = true
+		return g.events.Publish(GameOver{})
+	}
+	return nil
+}
+
+func (g *Game) checkWallCollision(s *sprites.CharacterSprite) bool {
+	// figure out if the current movement would result in a collision with a wal.
+	// we take the sprites current position width and height into account.
+	newX := s.X + s.CurrentVx
+	newY := s.Y + s.CurrentVy
+
+	// Calculate the tile coordinates
+	tileX1 := newX / 32
+	tileY1 := newY / 32
+	tileX2 := (newX + s.Width - 1) / 32
+	tileY2 := (newY + s.Height - 1) / 32
+
+	// Check all tiles the sprite would occupy
+	for y := tileY1; y <= tileY2; y++ {
+		for x := tileX1; x <= tileX2; x++ {
+			if x < 0 || x >= mapWidth || y < 0 || y >= mapHeight {
+				return true // Out of bounds is treated as a wall
+			}
+			if g.mapTiles[y][x] == 1 {
+				return true // Collision with wall
+			}
+		}
+	}
+	return false
+}
+
+// animate advances all animations by one tick of the simulation, so they stay
+// in sync with the game logic even if frames are dropped.
+func (g *Game) animate() {
+	dt := time.Second / time.Duration(ebiten.TPS())
+	for _, p := range g.players {
+		p.Animate(dt)
+	}
+	for _, enemy := range g.enemies {
+		enemy.Animate(dt)
+	}
+	for _, bite := range g.activeBites {
+		bite.Animate(dt)
+	}
+}
+
+// Update handles the game logic, primarily input and state changes.
+func (g *Game) Update() error {
+	if g.watcher != nil && time.Since(g.lastReloadCheck) > reloadInterval {
+		g.lastReloadCheck = time.Now()
+		g.reloadChangedAssets()
+	}
+
+	// Example: Exit on pressing Escape
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		if g.session != nil {
+			g.session.Close()
+		}
+		return ebiten.Termination
+	}
+
+	g.handleSubmit()
+	g.updateAudio()
+	if g.handleAchievementScreen() && g.session == nil {
+		// Local games are paused while the achievements are shown.
+		return nil
+	}
+
+	inputs, ok := g.readInputs()
+	if !ok {
+		return nil
+	}
+	err := g.Tick(inputs)
+	if err != nil {
+		return err
+	}
+	if g.session != nil {
+		g.checkSync()
+	}
+	return nil
+}
+
+// readInputs returns the inputs of all players for the next tick. It returns
+// false if the tick can't be simulated yet.
+func (g *Game) readInputs() ([]Input, bool) {
+	if g.session != nil {
+		return g.readNetworkInputs()
+	}
+	inputs := make([]Input, len(g.players))
+	for i, p := range g.players {
+		if p.Bot {
+			inputs[i] = g.BotInput(i)
+			continue
+		}
+		inputs[i] = p.Controls.Read()
+	}
+	return inputs, true
+}
+
+// Tick advances the simulation by one step, with the given input for each player.
+func (g *Game) Tick(inputs []Input) error {
+	if !g.Finished() {
+		g.recordInputs(inputs)
+	}
+	for i, p := range g.players {
+		p.input = inputs[i]
+	}
+	g.animate()
+
+	if g.Ended {
+		for _, p := range g.players {
+			if p.input&InputRestart == 0 {
+				continue
+			}
+			err := g.Reset()
+			if err != nil {
+				return fmt.Errorf("failed to reset game: %w", err)
+			}
+			break
+		}
+		return nil
+	}
+	g.levelTicks++
+	err := g.checkGameEnd()
+	if err != nil {
+		return err
+	}
+	err = g.checkBiteEaten()
+	if err != nil {
+		return err
+	}
+	err = g.expireBites()
+	if err != nil {
+		return err
+	}
+
+	g.handleInputAndMovement()
+
+	return nil
+}
+
+// hasBiteBeenEaten reports whether every team has already eaten the bite.
+func (g *Game) hasBiteBeenEaten(bite *sprites.CharacterSprite) bool {
+	for _, team := range g.teams {
+		if !team.hasBiteBeenEaten(bite) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Game) isBiteOnField(bite *sprites.CharacterSprite) bool {
+	for _, activeBite := range g.activeBites {
+		if bite.Id == activeBite.Id {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Game) checkBiteEaten() error {
+	for _, p := range g.alivePlayers() {
+		var eaten []*sprites.CharacterSprite
+		for bite := range g.biteIndex.Query(p.CollisionArea(p.Pickup(), maxReach(g.bites))) {
+			if p.PicksUp(bite) {
+				eaten = append(eaten, bite)
+			}
+		}
+		// Bites are eaten in the order they were placed.
+		for i := 0; i < len(g.activeBites); i++ {
+			bite := g.activeBites[i]
+			if !slices.Contains(eaten, &bite.CharacterSprite) {
+				continue
+			}
+			g.removeBite(i)
+			i--
+			err := g.eatBite(p, &bite.CharacterSprite)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return g.fillBites()
+}
+
+// maxReach returns how far the hitboxes of copies of the templates reach from
+// their centers, to find them in an index.
+func maxReach(templates []*sprites.CharacterSprite) int {
+	r := 0
+	for _, t := range templates {
+		r = max(r, t.Reach())
+	}
+	return r
+}
+
+// removeBite takes the i-th bite off the field.
+func (g *Game) removeBite(i int) {
+	g.activeBites[i].Untrack()
+	g.activeBites = slices.Delete(g.activeBites, i, i+1)
+}
+
+func (g *Game) eatBite(p *PlayerSlot, bite *sprites.CharacterSprite) error {
+	g.advanceGoal(p.Team, bite)
+	if !p.Team.hasBiteBeenEaten(bite) {
+		p.Team.eatenBites = append(p.Team.eatenBites, *bite)
+		p.Points += 500 + 100*len(g.enemies)
+		return g.events.Publish(BiteEaten{Player: p, Bite: bite})
+	}
+	p.Points += 100 * len(g.enemies)
+	p.Team.duplicates++
+	err := g.events.Publish(DuplicateEaten{Player: p, Bite: bite})
+	if err != nil {
+		return err
+	}
+	return g.placeNewEnemy()
+}
+
+// expireBites removes bites that reached the end of their lifetime.
+func (g *Game) expireBites() error {
+	g.activeBites = slices.DeleteFunc(g.activeBites, func(b *Bite) bool {
+		if b.TicksLeft != 1 {
+			return false
+		}
+		b.Untrack()
+		return true
+	})
+	for _, bite := range g.activeBites {
+		if bite.TicksLeft > 0 {
+			bite.TicksLeft--
+		}
+	}
+	return g.fillBites()
+}
+
+// fillBites places new bites until the field has as many as the level allows.
+func (g *Game) fillBites() error {
+	for len(g.activeBites) < g.levels[g.CurrentLevel].maxBites() {
+		err := g.placeNewBite()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset starts the current level, or the first level if the game was lost.
+func (g *Game) Reset() error {
+	g.StartBackgroundMusic()
+	if g.players == nil || g.Lost || g.Completed {
+		players, teams, err := g.newPlayers()
+		if err != nil {
+			return err
+		}
+		g.players = players
+		g.teams = teams
+		g.players[0].Bot = g.bot
+		g.Lost = false
+		g.Completed = false
+		g.CurrentLevel = g.startLevel
+
+		// Every game gets its own seed, so it can be replayed on its own.
+		seed := g.nextSeed
+		g.rng = rand.New(rand.NewPCG(seed, seed))
+		g.nextSeed = g.rng.Uint64()
+		g.startReplay(seed)
+		g.submitStatus = ""
+	}
+
+	for _, team := range g.teams {
+		team.reset()
+	}
+	for _, p := range g.players {
+		p.Dead = false
+		p.DeathCause = ""
+	}
+	g.levelTicks = 0
+	g.invalidateMap()
+	g.enemies = nil
+	g.enemyIndex.Clear()
+	g.biteIndex.Clear()
+	tiles, ok := g.maps[g.levels[g.CurrentLevel].Tiles]
+	if !ok {
+		return fmt.Errorf("map %s isn't loaded", g.levels[g.CurrentLevel].Tiles)
+	}
+	g.mapTiles = tiles
+	g.activeBites = nil
+	err := g.fillBites()
+	if err != nil {
+		return err
+	}
+	for range g.levels[g.CurrentLevel].StartEnemies {
+		err := g.placeNewEnemy()
+		if err != nil {
+			return err
+		}
+	}
+	g.title = GameTitle{
+		Visible:      true,
+		Duration:     5 * time.Second,
+		StartTime:    g.now(),
+		WordsVisible: 0,
+		ShakeX:       0,
+		ShakeY:       0,
+		Text:         g.goal().Title(),
+	}
+	g.Ended = false
+
+	for _, p := range g.players {
+		x, y, err := g.GetRandomFloorPosition(64)
+		if err != nil {
+			return fmt.Errorf("failed to place player: %w", err)
+		}
+		p.SetPosition(x, y)
+	}
+	//select random tile to spawn slime
+	return g.events.Publish(LevelStarted{Level: g.CurrentLevel})
+}
+
+func (g *Game) placeNewBite() error {
+	template := g.bites[g.rng.IntN(len(g.bites))]
+	// retry if bite is already eaten or on the field, according to level reoccurrance settings
+	for range g.levels[g.CurrentLevel].ReoccurranceRetry {
+		if !g.hasBiteBeenEaten(template) && !g.isBiteOnField(template) {
+			break
+		}
+		template = g.bites[g.rng.IntN(len(g.bites))]
+	}
+
+	bite := &Bite{CharacterSprite: *template}
+	if lifetime := g.levels[g.CurrentLevel].BiteLifetime; lifetime > 0 {
+		bite.TicksLeft = lifetime * ebiten.TPS()
+	}
+	var err error
+	bite.X, bite.Y, err = g.getFreeBitePosition()
+	if err != nil {
+		return fmt.Errorf("failed to place bite: %w", err)
+	}
+	bite.Track(g.biteIndex)
+	g.activeBites = append(g.activeBites, bite)
+	return nil
+}
+
+// getFreeBitePosition returns a floor position that isn't taken by another bite.
+func (g *Game) getFreeBitePosition() (int, int, error) {
+	return g.randomFloorPosition(64, func(x, y int) bool {
+		// Bites are placed on tiles, so another bite on the tile has its center in it.
+		for bite := range g.biteIndex.Query(image.Rect(x, y, x+32, y+32)) {
+			if bite.X == x && bite.Y == y {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// isFreeOfEnemies reports whether the enemy wouldn't overlap another enemy at
+// x, y.
+func (g *Game) isFreeOfEnemies(enemy *sprites.CharacterSprite) func(x, y int) bool {
+	return func(x, y int) bool {
+		placed := *enemy
+		placed.X, placed.Y = x, y
+		for other := range g.enemyIndex.Query(placed.CollisionArea(placed.Hitbox(), maxReach(g.enemyTemplates))) {
+			if placed.CheckCollision(other) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func (g *Game) placeNewEnemy() error {
+	slimeSprite := *g.enemyTemplates[g.rng.IntN(len(g.enemyTemplates))]
+	var err error
+	slimeSprite.X, slimeSprite.Y, err = g.randomFloorPosition(64, g.isFreeOfEnemies(&slimeSprite))
+	if err != nil {
+		// Enemies are stacked once the map is too crowded to keep them apart.
+		slimeSprite.X, slimeSprite.Y, err = g.GetRandomFloorPosition(64)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to place enemy: %w", err)
+	}
+	slimeSprite.Track(g.enemyIndex)
+	g.enemies = append(g.enemies, &slimeSprite)
+	return g.events.Publish(EnemySpawned{X: slimeSprite.X, Y: slimeSprite.Y, AtLevelStart: g.levelTicks == 0})
+}
+
+// StartBackgroundMusic plays the soundtrack of the current level. It fades in,
+// or crossfades with the soundtrack of the previous level. The game continues
+// without music if it can't be played.
+func (g *Game) StartBackgroundMusic() {
+	if g.audio == nil {
+		return
+	}
+	soundtrack := g.soundtrack()
+	fade := seconds(soundtrack.FadeIn)
+	if g.audio.MusicPlaying() {
+		fade = seconds(soundtrack.Crossfade)
+	}
+	err := g.audio.PlayMusic(soundtrack.Name, fade)
+	if err != nil {
+		g.reportAudioError(fmt.Errorf("failed to start background music: %w", err))
+	}
+}
+
+// drawMap draws the tiles of the map. They are rendered once into bgImage, and
+// again only after the tiles changed.
+func (g *Game) drawMap(screen *ebiten.Image) {
+	if g.bgImage == nil {
+		g.bgImage = ebiten.NewImage(ScreenWidth, ScreenHeight)
+		for y := range mapHeight {
+			for x := range mapWidth {
+				tile := g.mapTiles[y][x]
+				op := &ebiten.DrawImageOptions{}
+				op.GeoM.Translate(float64(x*32), float64(y*32))
+				tileImg := g.floorTile
+				if tile == 1 {
+					tileImg = g.wallTile
+				}
+				g.bgImage.DrawImage(tileImg, op)
+			}
+		}
+	}
+	screen.DrawImage(g.bgImage, nil)
+}
+
+// invalidateMap renders the map again before it is drawn next.
+func (g *Game) invalidateMap() {
+	if g.bgImage != nil {
+		g.bgImage.Deallocate()
+		g.bgImage = nil
+	}
+}
+
+// Draw renders the game state to the screen.
+func (g *Game) Draw(screen *ebiten.Image) {
+	g.drawMap(screen)
+
+	// --- Draw Bites ---
+	for _, bite := range g.activeBites {
+		if !bite.Visible() {
+			continue
+		}
+		biteOp := &ebiten.DrawImageOptions{}
+		biteOp.GeoM.Translate(float64(bite.X), float64(bite.Y))
+		biteImg := bite.GetCurrentImage()
+		screen.DrawImage(biteImg, biteOp)
+	}
+
+	// --- Draw Players ---
+	for _, p := range g.alivePlayers() {
+		playerOp := &ebiten.DrawImageOptions{}
+		playerOp.GeoM.Translate(float64(p.X), float64(p.Y))
+		playerOp.ColorScale.ScaleWithColor(p.Tint)
+		playerImg := p.GetCurrentImage()
+		screen.DrawImage(playerImg, playerOp)
+	}
+
+	for _, enemy := range g.enemies {
+		slimeOp := &ebiten.DrawImageOptions{}
+		slimeOp.GeoM.Translate(float64(enemy.X), float64(enemy.Y))
+		slimeImg := enemy.GetCurrentImage()
+		screen.DrawImage(slimeImg, slimeOp)
+	}
+
+	// The first team's bites are listed from the left, the second team's from the right.
+	for t, team := range g.teams {
+		for i, bite := range team.eatenBites {
+			eatenBiteOp := &ebiten.DrawImageOptions{}
+			x := float64(i) * 32
+			if t == 1 {
+				x = ScreenWidth - float64(i+1)*32
+			}
+			eatenBiteOp.GeoM.Translate(x, 0)
+			eatenBiteImg := bite.GetFirstImage()
+			screen.DrawImage(eatenBiteImg, eatenBiteOp)
+		}
+	}
+
+	g.drawScore(screen)
+	g.drawGoal(screen)
+
+	// Draw title on new level
+	if g.title.Visible {
+		g.drawTitle(screen)
+	}
+	g.drawSubmit(screen)
+	g.drawAchievements(screen)
+}
+
+func (g *Game) drawScore(screen *ebiten.Image) {
+	for i, p := range g.players {
+		numToDraw := p.Points
+		if p.Points > p.LastPoints {
+			numToDraw = p.LastPoints
+			p.LastPoints += (p.Points-p.LastPoints)/10 + 1
+		}
+		// draw score with 10 leading zeros
+		pointsText := fmt.Sprintf("Score: %010d", numToDraw)
+		if len(g.players) > 1 {
+			pointsText = fmt.Sprintf("P%d %010d", p.Number, numToDraw)
+		}
+		t := g.hudText(fmt.Sprintf("score %d", i), pointsText, 16)
+		x := 32.0
+		if i == 1 {
+			// The second player's score is aligned to the right.
+			x = ScreenWidth - 32 - t.width
+		}
+		t.draw(screen, x, ScreenHeight-24, p.Tint)
+	}
+}
+
+func (g *Game) drawTitle(screen *ebiten.Image) {
+	words := strings.Split(g.title.Text, " ")
+	title := g.hudText("title", g.title.Text, 24)
+	x, y := ScreenWidth/2-title.width/2, ScreenHeight/2-title.height/2
+
+	//half-transparent block around text
+	vector.DrawFilledRect(screen, float32(x-10), float32(y-10), float32(int(title.width)+20), float32(int(title.height)+20), color.RGBA{220, 220, 225, 0}, false)
+
+	now := g.now()
+	if now.Sub(g.title.LastShakeTime) > 50*time.Millisecond {
+		g.title.ShakeX = g.effects.IntN(6) - 3
+		g.title.ShakeY = g.effects.IntN(6) - 3
+		g.title.LastShakeTime = now
+	}
+	x += float64(g.title.ShakeX)
+	y += float64(g.title.ShakeY)
+	for i := 0; i < g.title.WordsVisible && i < len(words); i++ {
+		word := g.hudText(fmt.Sprintf("title word %d", i), words[i]+" ", 24)
+		word.draw(screen, x, y, color.Gray{})
+		x += word.width
+	}
+	if now.Sub(g.title.StartTime) > time.Second*time.Duration(g.title.WordsVisible) && g.title.WordsVisible < len(words) {
+		g.title.WordsVisible++
+	}
+	if now.Sub(g.title.StartTime) > g.title.Duration {
+		g.title.Visible = false
+	}
+}
+
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return ScreenWidth, ScreenHeight
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/NautiluX/8bites/pkg/sprites"
+)
+
+func TestLevelValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		level Level
+		// err is part of the expected error, none is expected if it is empty.
+		err string
+	}{
+		{"defaults", Level{}, ""},
+		{"bites", Level{ActiveBites: maxActiveBites, BiteLifetime: maxBiteLifetime}, ""},
+		{"negative active bites", Level{ActiveBites: -1}, "ActiveBites"},
+		{"too many active bites", Level{ActiveBites: maxActiveBites + 1}, "ActiveBites"},
+		{"negative lifetime", Level{BiteLifetime: -1}, "BiteLifetime"},
+		{"lifetime too long", Level{BiteLifetime: maxBiteLifetime + 1}, "BiteLifetime"},
+		{"invalid goal", Level{Goal: Goal{Type: GoalScore}}, "invalid goal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.level.validate()
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("valid level is rejected: %v", err)
+			case tt.err != "" && err == nil:
+				t.Errorf("invalid level is accepted")
+			case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+				t.Errorf("error is %q, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// floorMap returns a map of walls with the given number of floor tiles.
+func floorMap(floor int) [mapHeight][mapWidth]int {
+	var tiles [mapHeight][mapWidth]int
+	for i := range mapWidth * mapHeight {
+		if i >= floor {
+			tiles[i/mapWidth][i%mapWidth] = 1
+		}
+	}
+	return tiles
+}
+
+func TestCheckMap(t *testing.T) {
+	level := Level{Tiles: "small", ActiveBites: 4}
+	if err := level.checkMap(floorMap(4 + playerClearance)); err != nil {
+		t.Errorf("map with room for the bites is rejected: %v", err)
+	}
+	if err := level.checkMap(floorMap(3 + playerClearance)); err == nil {
+		t.Error("map without room for the bites is accepted")
+	}
+}
+
+func TestPlacementGivesUp(t *testing.T) {
+	g := &Game{rng: rand.New(rand.NewPCG(1, 1)), mapTiles: floorMap(2), biteIndex: sprites.NewIndex()}
+	placeBite := func(x, y int) {
+		bite := &Bite{CharacterSprite: sprites.CharacterSprite{X: x, Y: y, Width: 32, Height: 32}}
+		bite.Track(g.biteIndex)
+		g.activeBites = append(g.activeBites, bite)
+	}
+	placeBite(0, 0)
+	x, y, err := g.getFreeBitePosition()
+	if err != nil || x != 32 || y != 0 {
+		t.Fatalf("free position is %d,%d (%v), want 32,0", x, y, err)
+	}
+	placeBite(32, 0)
+	_, _, err = g.getFreeBitePosition()
+	if err == nil {
+		t.Error("a bite was placed on a full map")
+	}
+}
+
+func TestEnemiesSpawnApart(t *testing.T) {
+	a, err := LoadAssets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGame(Config{Mode: ModeSingle, Assets: a, Seed: 1, Headless: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 30 {
+		err := g.placeNewEnemy()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, enemy := range g.enemies {
+		for _, other := range g.enemies[i+1:] {
+			if enemy.CheckCollision(other) {
+				t.Errorf("enemies at %d,%d and %d,%d overlap", enemy.X, enemy.Y, other.X, other.Y)
+			}
+		}
+	}
+}
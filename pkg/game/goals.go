@@ -0,0 +1,222 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strings"
+
+	"github.com/NautiluX/8bites/pkg/sprites"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type GoalType string
+
+const (
+	// GoalDistinct is reached after eating Count different bites.
+	GoalDistinct GoalType = "distinct"
+	// GoalSequence is reached after eating Bites in the given order.
+	GoalSequence GoalType = "sequence"
+	// GoalRecipe is reached after eating all of Bites, in any order.
+	GoalRecipe GoalType = "recipe"
+	// GoalScore is reached after scoring Score points in the level.
+	GoalScore GoalType = "score"
+	// GoalSurvive is reached after staying alive for Seconds.
+	GoalSurvive GoalType = "survive"
+	// GoalFoodGroup is reached after eating every bite of Group.
+	GoalFoodGroup GoalType = "food_group"
+)
+
+// Goal defines how a level is won. Only the fields used by its Type are set.
+type Goal struct {
+	Type    GoalType
+	Count   int
+	Bites   []string
+	Score   int
+	Seconds int
+	Group   string
+}
+
+// foodGroup returns the names of the bites in the group, none if there is no
+// such group.
+func foodGroup(group string) []string {
+	var names []string
+	for _, b := range biteKinds {
+		if b.group == group {
+			names = append(names, b.name)
+		}
+	}
+	return names
+}
+
+// withDefaults returns the goal with unset fields filled, so levels without a
+// goal are won by eating 8 different bites.
+func (goal Goal) withDefaults() Goal {
+	if goal.Type == "" {
+		goal.Type = GoalDistinct
+	}
+	if goal.Type == GoalDistinct && goal.Count == 0 {
+		goal.Count = 8
+	}
+	return goal
+}
+
+func (goal Goal) validate() error {
+	isBite := func(name string) bool {
+		for _, b := range biteKinds {
+			if b.name == name {
+				return true
+			}
+		}
+		return false
+	}
+	switch goal.Type {
+	case GoalDistinct:
+		if goal.Count <= 0 {
+			return fmt.Errorf("goal %s needs a positive Count", goal.Type)
+		}
+		if goal.Count > len(biteKinds) {
+			return fmt.Errorf("goal %s can't be reached with a Count above the %d different bites", goal.Type, len(biteKinds))
+		}
+	case GoalSequence, GoalRecipe:
+		if len(goal.Bites) == 0 {
+			return fmt.Errorf("goal %s needs Bites", goal.Type)
+		}
+		for i, bite := range goal.Bites {
+			if !isBite(bite) {
+				return fmt.Errorf("unknown bite %q in goal %s", bite, goal.Type)
+			}
+			// Eating a bite again counts as a duplicate, so it can't be an ingredient twice.
+			if goal.Type == GoalRecipe && slices.Contains(goal.Bites[:i], bite) {
+				return fmt.Errorf("bite %q is used more than once in goal %s", bite, goal.Type)
+			}
+		}
+	case GoalScore:
+		if goal.Score <= 0 {
+			return fmt.Errorf("goal %s needs a positive Score", goal.Type)
+		}
+	case GoalSurvive:
+		if goal.Seconds <= 0 {
+			return fmt.Errorf("goal %s needs positive Seconds", goal.Type)
+		}
+	case GoalFoodGroup:
+		if len(foodGroup(goal.Group)) == 0 {
+			return fmt.Errorf("unknown food group %q", goal.Group)
+		}
+	default:
+		return fmt.Errorf("unknown goal type %q", goal.Type)
+	}
+	return nil
+}
+
+// Title is shown when the level starts.
+func (goal Goal) Title() string {
+	switch goal.Type {
+	case GoalSequence:
+		return strings.ToUpper(strings.Join(goal.Bites, " THEN ")) + "!"
+	case GoalRecipe:
+		return "COOK " + strings.ToUpper(strings.Join(goal.Bites, " + ")) + "!"
+	case GoalScore:
+		return fmt.Sprintf("SCORE %d POINTS!", goal.Score)
+	case GoalSurvive:
+		return fmt.Sprintf("SURVIVE %d SECONDS!", goal.Seconds)
+	case GoalFoodGroup:
+		return "EAT ALL " + strings.ToUpper(goal.Group) + "!"
+	}
+	return fmt.Sprintf("%d BITES TO WIN!", goal.Count)
+}
+
+func (g *Game) goal() Goal {
+	return g.levels[g.CurrentLevel].Goal.withDefaults()
+}
+
+// advanceGoal records a bite that was eaten, for goals that depend on the order.
+func (g *Game) advanceGoal(team *Team, bite *sprites.CharacterSprite) {
+	goal := g.goal()
+	if goal.Type != GoalSequence || team.goalStep >= len(goal.Bites) {
+		return
+	}
+	if g.assets.biteNames[bite.Id] == goal.Bites[team.goalStep] {
+		team.goalStep++
+	}
+}
+
+// countEaten returns how many of the named bites the team ate.
+func (g *Game) countEaten(team *Team, names []string) int {
+	count := 0
+	for _, bite := range team.eatenBites {
+		if slices.Contains(names, g.assets.biteNames[bite.Id]) {
+			count++
+		}
+	}
+	return count
+}
+
+// goalProgress returns how far the team got towards the goal of the level,
+// and the progress at which it is reached.
+func (g *Game) goalProgress(team *Team) (int, int) {
+	return g.progressTowards(g.goal(), team)
+}
+
+// progressTowards returns how far the team got towards the goal, and the
+// progress at which it is reached.
+func (g *Game) progressTowards(goal Goal, team *Team) (int, int) {
+	switch goal.Type {
+	case GoalSequence:
+		return team.goalStep, len(goal.Bites)
+	case GoalRecipe:
+		return g.countEaten(team, goal.Bites), len(goal.Bites)
+	case GoalScore:
+		return team.Points() - team.levelStartPoints, goal.Score
+	case GoalSurvive:
+		return g.levelTicks / ebiten.TPS(), goal.Seconds
+	case GoalFoodGroup:
+		group := foodGroup(goal.Group)
+		return g.countEaten(team, group), len(group)
+	}
+	return len(team.eatenBites), goal.Count
+}
+
+func (g *Game) goalReached(team *Team) bool {
+	progress, target := g.goalProgress(team)
+	return progress >= target
+}
+
+// drawGoal shows the progress towards the goal of the level next to the score.
+// With two players it is shown in short form between the scores.
+func (g *Game) drawGoal(screen *ebiten.Image) {
+	goal := g.goal()
+	var goalTexts []string
+	for _, team := range g.teams {
+		progress, target := g.goalProgress(team)
+		progress = min(progress, target)
+		switch {
+		case goal.Type == GoalSurvive && len(g.players) > 1:
+			goalTexts = append(goalTexts, fmt.Sprintf("%d/%ds", progress, target))
+		case goal.Type == GoalSurvive:
+			goalTexts = append(goalTexts, fmt.Sprintf("Time: %d/%ds", progress, target))
+		case len(g.players) > 1:
+			goalTexts = append(goalTexts, fmt.Sprintf("%d/%d", progress, target))
+		case goal.Type == GoalSequence && team.goalStep < len(goal.Bites):
+			goalTexts = append(goalTexts, fmt.Sprintf("Next: %s", goal.Bites[team.goalStep]))
+		case goal.Type == GoalSequence:
+			goalTexts = append(goalTexts, "Done!")
+		case goal.Type == GoalScore:
+			goalTexts = append(goalTexts, fmt.Sprintf("Goal: %d%%", progress*100/target))
+		default:
+			goalTexts = append(goalTexts, fmt.Sprintf("Bites: %d/%d", progress, target))
+		}
+	}
+	if goal.Type == GoalSurvive {
+		// The time is the same for all teams.
+		goalTexts = goalTexts[:1]
+	}
+	goalText := strings.Join(goalTexts, " : ")
+
+	t := g.hudText("goal", goalText, 16)
+	x := ScreenWidth - 32 - t.width
+	if len(g.players) > 1 {
+		x = ScreenWidth/2 - t.width/2
+	}
+	t.draw(screen, x, ScreenHeight-24, color.White)
+}
@@ -0,0 +1,109 @@
+package game
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NautiluX/8bites/pkg/sprites"
+)
+
+// goalGame returns a game in a level with the goal, without loading any assets.
+func goalGame(goal Goal) *Game {
+	a := &Assets{biteNames: map[sprites.SpriteId]string{}}
+	for _, b := range biteKinds {
+		a.biteNames[b.id] = b.name
+	}
+	return &Game{assets: a, levels: []Level{{Goal: goal}}}
+}
+
+// biteSprite returns a sprite of the named bite.
+func biteSprite(t *testing.T, name string) *sprites.CharacterSprite {
+	t.Helper()
+	for _, b := range biteKinds {
+		if b.name == name {
+			return &sprites.CharacterSprite{Id: b.id}
+		}
+	}
+	t.Fatalf("there is no bite %s", name)
+	return nil
+}
+
+func TestGoalProgress(t *testing.T) {
+	tests := []struct {
+		name       string
+		goal       Goal
+		eaten      []string
+		points     int
+		startTicks int
+		want       int
+		wantTarget int
+	}{
+		{"no goal", Goal{}, []string{"apple", "cheese", "donut"}, 0, 0, 3, 8},
+		{"distinct", Goal{Type: GoalDistinct, Count: 4}, []string{"apple", "cheese"}, 0, 0, 2, 4},
+		{"sequence", Goal{Type: GoalSequence, Bites: []string{"apple", "banana", "apple"}}, []string{"banana", "apple", "banana", "cheese"}, 0, 0, 2, 3},
+		{"recipe", Goal{Type: GoalRecipe, Bites: []string{"apple", "banana", "cheese"}}, []string{"apple", "cheese", "donut"}, 0, 0, 2, 3},
+		{"score", Goal{Type: GoalScore, Score: 100}, nil, 150, 0, 70, 100},
+		{"survive", Goal{Type: GoalSurvive, Seconds: 10}, nil, 0, 5*60 + 30, 5, 10},
+		{"food group", Goal{Type: GoalFoodGroup, Group: "fruit"}, []string{"apple", "cheese", "banana"}, 0, 0, 2, 4},
+		{"single bite food group", Goal{Type: GoalFoodGroup, Group: "sweet"}, []string{"donut"}, 0, 0, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := goalGame(tt.goal)
+			g.levelTicks = tt.startTicks
+			team := &Team{levelStartPoints: 80}
+			team.players = []*PlayerSlot{{Player: &sprites.Player{Points: tt.points}, Team: team}}
+			for _, name := range tt.eaten {
+				bite := biteSprite(t, name)
+				g.advanceGoal(team, bite)
+				team.eatenBites = append(team.eatenBites, *bite)
+			}
+			progress, target := g.goalProgress(team)
+			if progress != tt.want || target != tt.wantTarget {
+				t.Errorf("progress is %d/%d, want %d/%d", progress, target, tt.want, tt.wantTarget)
+			}
+			if g.goalReached(team) != (tt.want >= tt.wantTarget) {
+				t.Errorf("goal reached is %v at %d/%d", g.goalReached(team), progress, target)
+			}
+		})
+	}
+}
+
+func TestGoalValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		goal Goal
+		// err is part of the expected error, none is expected if it is empty.
+		err string
+	}{
+		{"default", Goal{}.withDefaults(), ""},
+		{"distinct", Goal{Type: GoalDistinct, Count: 8}, ""},
+		{"distinct without count", Goal{Type: GoalDistinct}, "positive Count"},
+		{"distinct beyond the bites", Goal{Type: GoalDistinct, Count: 9}, "Count above the 8 different bites"},
+		{"sequence", Goal{Type: GoalSequence, Bites: []string{"apple", "apple"}}, ""},
+		{"sequence without bites", Goal{Type: GoalSequence}, "needs Bites"},
+		{"sequence with unknown bite", Goal{Type: GoalSequence, Bites: []string{"apple", "kiwi"}}, `unknown bite "kiwi"`},
+		{"recipe", Goal{Type: GoalRecipe, Bites: []string{"apple", "cheese"}}, ""},
+		{"recipe with duplicate", Goal{Type: GoalRecipe, Bites: []string{"apple", "cheese", "apple"}}, `bite "apple" is used more than once`},
+		{"score", Goal{Type: GoalScore, Score: 1}, ""},
+		{"score without points", Goal{Type: GoalScore}, "positive Score"},
+		{"survive", Goal{Type: GoalSurvive, Seconds: 1}, ""},
+		{"survive without time", Goal{Type: GoalSurvive, Seconds: -1}, "positive Seconds"},
+		{"food group", Goal{Type: GoalFoodGroup, Group: "savory"}, ""},
+		{"unknown food group", Goal{Type: GoalFoodGroup, Group: "meat"}, `unknown food group "meat"`},
+		{"unknown type", Goal{Type: "eat_all"}, `unknown goal type "eat_all"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.goal.validate()
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("valid goal is rejected: %v", err)
+			case tt.err != "" && err == nil:
+				t.Errorf("invalid goal is accepted")
+			case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+				t.Errorf("error is %q, want %q", err, tt.err)
+			}
+		})
+	}
+}
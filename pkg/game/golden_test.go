@@ -0,0 +1,265 @@
+package game
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	compareGolden   = flag.Bool("golden", false, "compare rendered scenes with the golden images in testdata/golden, needs a display")
+	updateGolden    = flag.Bool("update-golden", false, "replace the golden images with the current rendering")
+	goldenDiffs     = flag.String("golden-diffs", "golden-diffs", "directory mismatching renderings and their diffs are written to")
+	goldenTolerance = flag.Int("golden-tolerance", 8, "how much a color channel may differ before the pixel counts as different")
+	goldenMaxDiff   = flag.Float64("golden-max-diff", 0.001, "fraction of pixels of a region that may differ")
+)
+
+// goldenSettleFrames is how often a scene is drawn before it is captured, so
+// animated text like the score counter and the title settles.
+const goldenSettleFrames = 60
+
+// goldenScene is a state of the game that is rendered and compared.
+type goldenScene struct {
+	name string
+	mode GameMode
+	seed uint64
+	// ticks are simulated with the bot playing the first player.
+	ticks int
+	// elapsed is the time since the level started when the scene is drawn.
+	elapsed time.Duration
+}
+
+var goldenScenes = []goldenScene{
+	{name: "level_start", mode: ModeSingle, seed: 1, elapsed: 2500 * time.Millisecond},
+	{name: "title", mode: ModeSingle, seed: 1, elapsed: 4500 * time.Millisecond},
+	{name: "playing", mode: ModeSingle, seed: 1, ticks: 900, elapsed: 10 * time.Second},
+	{name: "coop", mode: ModeCoop, seed: 2, ticks: 900, elapsed: 10 * time.Second},
+}
+
+// goldenRegion is a part of the screen drawn by one function of the game.
+// Every region is compared on its own, so a failure names what changed.
+type goldenRegion struct {
+	name   string
+	bounds image.Rectangle
+}
+
+var goldenRegions = []goldenRegion{
+	// The map is drawn on the whole screen, the rows of the eaten bites and the
+	// score are left to their regions.
+	{"map", image.Rect(0, 32, ScreenWidth, ScreenHeight-32)},
+	{"eaten bites", image.Rect(0, 0, ScreenWidth, 32)},
+	{"score", image.Rect(0, ScreenHeight-32, ScreenWidth, ScreenHeight)},
+	{"title", image.Rect(0, ScreenHeight/2-40, ScreenWidth, ScreenHeight/2+40)},
+}
+
+// testLoop runs the tests in its first update, as pixels can only be read
+// while the game loop runs.
+type testLoop struct {
+	m    *testing.M
+	code int
+}
+
+func (l *testLoop) Update() error {
+	l.code = l.m.Run()
+	return ebiten.Termination
+}
+
+func (l *testLoop) Draw(screen *ebiten.Image) {}
+
+func (l *testLoop) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return ScreenWidth, ScreenHeight
+}
+
+// TestMain runs the tests inside the game loop when the golden images are
+// compared, and without a display otherwise.
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if !*compareGolden && !*updateGolden {
+		os.Exit(m.Run())
+	}
+	l := &testLoop{m: m, code: 1}
+	ebiten.SetWindowSize(ScreenWidth, ScreenHeight)
+	ebiten.SetWindowTitle("8bites golden images")
+	err := ebiten.RunGame(l)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(l.code)
+}
+
+func TestGolden(t *testing.T) {
+	if !*compareGolden && !*updateGolden {
+		t.Skip("golden images are compared with -golden")
+	}
+	a, err := LoadAssets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range goldenScenes {
+		t.Run(s.name, func(t *testing.T) {
+			img, err := renderScene(a, s)
+			if err != nil {
+				t.Fatalf("failed to render: %v", err)
+			}
+			checkGolden(t, s.name, img)
+		})
+	}
+}
+
+// renderScene plays the scene and returns what the game draws at its end.
+func renderScene(a *Assets, s goldenScene) (*image.RGBA, error) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	g, err := NewGame(Config{
+		Mode:     s.mode,
+		Assets:   a,
+		Seed:     s.seed,
+		Headless: true,
+		Bot:      true,
+		Clock:    func() time.Time { return now },
+	})
+	if err != nil {
+		return nil, err
+	}
+	inputs := make([]Input, len(g.Players()))
+	for range s.ticks {
+		inputs[0] = g.BotInput(0)
+		err := g.Tick(inputs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	now = start.Add(s.elapsed)
+
+	screen := ebiten.NewImage(ScreenWidth, ScreenHeight)
+	defer screen.Deallocate()
+	for range goldenSettleFrames {
+		screen.Clear()
+		g.Draw(screen)
+	}
+	img := image.NewRGBA(screen.Bounds())
+	screen.ReadPixels(img.Pix)
+	return img, nil
+}
+
+// checkGolden compares the rendering with the golden image, or replaces the
+// golden with it on -update-golden. It writes the rendering and a diff to the
+// diff directory if they don't match.
+func checkGolden(t *testing.T, name string, actual *image.RGBA) {
+	t.Helper()
+	goldenPath := filepath.Join("testdata", "golden", name+".png")
+	if *updateGolden {
+		err := writePNG(goldenPath, actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	golden, err := readPNG(goldenPath)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Errorf("no golden image, create it with -update-golden")
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if golden.Bounds() != actual.Bounds() {
+		t.Fatalf("golden is %v, rendering is %v", golden.Bounds().Size(), actual.Bounds().Size())
+	}
+
+	// Matching pixels are shown dimmed in the diff, so the differences stand out.
+	diff := image.NewRGBA(actual.Bounds())
+	for y := range actual.Bounds().Dy() {
+		for x := range actual.Bounds().Dx() {
+			a := actual.RGBAAt(x, y)
+			if similar(a, golden.RGBAAt(x, y)) {
+				diff.SetRGBA(x, y, color.RGBA{a.R / 4, a.G / 4, a.B / 4, 255})
+			} else {
+				diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+			}
+		}
+	}
+	for _, r := range goldenRegions {
+		different := 0
+		for y := r.bounds.Min.Y; y < r.bounds.Max.Y; y++ {
+			for x := r.bounds.Min.X; x < r.bounds.Max.X; x++ {
+				if !similar(actual.RGBAAt(x, y), golden.RGBAAt(x, y)) {
+					different++
+				}
+			}
+		}
+		fraction := float64(different) / float64(r.bounds.Dx()*r.bounds.Dy())
+		if fraction > *goldenMaxDiff {
+			t.Errorf("%d pixels (%.2f%%) of the %s differ", different, fraction*100, r.name)
+		}
+	}
+	if !t.Failed() {
+		return
+	}
+	err = writePNG(filepath.Join(*goldenDiffs, name+".actual.png"), actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writePNG(filepath.Join(*goldenDiffs, name+".diff.png"), diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("rendering and diff are written to %s", *goldenDiffs)
+}
+
+// similar reports whether no channel of the colors differs by more than the tolerance.
+func similar(a, b color.RGBA) bool {
+	d := func(x, y uint8) int {
+		if x > y {
+			return int(x - y)
+		}
+		return int(y - x)
+	}
+	return max(d(a.R, b.R), d(a.G, b.G), d(a.B, b.B), d(a.A, b.A)) <= *goldenTolerance
+}
+
+func readPNG(path string) (*image.RGBA, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+			rgba.Set(x, y, img.At(x, y))
+		}
+	}
+	return rgba, nil
+}
+
+func writePNG(path string, img image.Image) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, img)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,183 @@
+package game
+
+import (
+	"log"
+	pathpkg "path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NautiluX/8bites/assets"
+	"github.com/NautiluX/8bites/pkg/sprites"
+)
+
+// reloadInterval is how often the mod directory is checked for changes.
+const reloadInterval = time.Second
+
+// affectsSimulation reports whether a changed file changes how a game plays
+// out, like the hitboxes of a sprite or the layout of a map.
+func affectsSimulation(path string) bool {
+	return path == "levels.json" || strings.HasPrefix(path, "maps/") || strings.HasPrefix(path, "sprites/")
+}
+
+// reloadChangedAssets applies all changes in the mod directory to the running
+// game. Changes that affect the simulation wait until the game is finished, so
+// its replay can be verified with the assets it was played with.
+func (g *Game) reloadChangedAssets() {
+	changed, err := g.watcher.Poll()
+	if err != nil {
+		log.Printf("failed to poll mod directory: %v", err)
+		return
+	}
+	for _, path := range changed {
+		if !slices.Contains(g.pendingReloads, path) {
+			g.pendingReloads = append(g.pendingReloads, path)
+		}
+	}
+	var pending []string
+	for _, path := range g.pendingReloads {
+		if affectsSimulation(path) && !g.Finished() {
+			if slices.Contains(changed, path) {
+				log.Printf("%s changed, it is reloaded when the game is over", path)
+			}
+			pending = append(pending, path)
+			continue
+		}
+		switch {
+		case path == "levels.json":
+			err = g.reloadLevels()
+		case strings.HasPrefix(path, "maps/") && strings.HasSuffix(path, ".txt"):
+			err = g.reloadMap(strings.TrimSuffix(strings.TrimPrefix(path, "maps/"), ".txt"))
+		case strings.HasPrefix(path, "sprites/") && strings.HasSuffix(path, ".png"):
+			err = g.reloadSprite(strings.TrimPrefix(path, "sprites/"))
+		case strings.HasPrefix(path, "sprites/") && strings.HasSuffix(path, ".aseprite"):
+			err = g.reloadSprite(strings.TrimSuffix(strings.TrimPrefix(path, "sprites/"), ".aseprite") + ".png")
+		case strings.HasPrefix(path, "sfx/") && g.audio != nil:
+			// The sound is loaded again the next time it is played.
+			g.audio.Forget(strings.TrimSuffix(strings.TrimPrefix(path, "sfx/"), pathpkg.Ext(path)))
+		default:
+			continue
+		}
+		if err != nil {
+			log.Printf("failed to reload %s: %v", path, err)
+			continue
+		}
+		log.Printf("reloaded %s", path)
+	}
+	g.pendingReloads = pending
+}
+
+// reloadLevels replaces the level settings. Settings used when a level starts,
+// like StartEnemies, take effect on the next reset.
+func (g *Game) reloadLevels() error {
+	l, err := loadLevels()
+	if err != nil {
+		return err
+	}
+	m, err := loadMaps(l)
+	if err != nil {
+		return err
+	}
+	g.levels = l
+	g.maps = m
+	if g.CurrentLevel >= len(g.levels) {
+		g.CurrentLevel = len(g.levels) - 1
+	}
+	g.mapTiles = g.maps[g.levels[g.CurrentLevel].Tiles]
+	g.invalidateMap()
+	return nil
+}
+
+// reloadMap replaces the map, if a level uses it.
+func (g *Game) reloadMap(name string) error {
+	if _, ok := g.maps[name]; !ok {
+		return nil
+	}
+	tiles, err := assets.GetMapTiles(name)
+	if err != nil {
+		return err
+	}
+	for _, level := range g.levels {
+		if level.Tiles != name {
+			continue
+		}
+		err := level.checkMap(tiles)
+		if err != nil {
+			return err
+		}
+	}
+	g.maps[name] = tiles
+	if name == g.levels[g.CurrentLevel].Tiles {
+		g.mapTiles = tiles
+		g.invalidateMap()
+	}
+	return nil
+}
+
+func (g *Game) reloadSprite(path string) error {
+	switch path {
+	case "world/wall.png", "world/floor.png":
+		img, err := assets.GetSprite(path)
+		if err != nil {
+			return err
+		}
+		if path == "world/wall.png" {
+			g.wallTile = img
+		} else {
+			g.floorTile = img
+		}
+		g.invalidateMap()
+		return nil
+	}
+
+	id, ok := g.assets.spriteFiles[path]
+	if !ok {
+		return nil
+	}
+	img, err := assets.GetSprite(path)
+	if err != nil {
+		return err
+	}
+	animations, err := assets.GetAnimations(path)
+	if err != nil {
+		return err
+	}
+	swap := func(s *sprites.CharacterSprite) {
+		if s.Id == id {
+			s.Image = img
+			s.Frames = img.Bounds().Dx() / s.Width
+			s.Animations = animations
+			if s.CurrentAnimation >= len(animations) {
+				s.CurrentAnimation = 0
+			}
+			if s.CurrentFrame >= animations[s.CurrentAnimation].Frames {
+				s.CurrentFrame = 0
+			}
+		}
+	}
+	if id == sprites.SpriteIdPlayer {
+		g.playerImage = img
+		g.playerAnimations = animations
+	}
+	for _, p := range g.players {
+		swap(&p.CharacterSprite)
+	}
+	for _, bite := range g.bites {
+		swap(bite)
+	}
+	for _, enemy := range g.enemyTemplates {
+		swap(enemy)
+	}
+	for _, enemy := range g.enemies {
+		swap(enemy)
+	}
+	for _, team := range g.teams {
+		for i := range team.eatenBites {
+			swap(&team.eatenBites[i])
+		}
+	}
+	for _, bite := range g.activeBites {
+		swap(&bite.CharacterSprite)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package game
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// hudText keeps a line of text rendered to an image, so it is only rendered
+// again when it changes.
+type hudText struct {
+	text   string
+	size   float64
+	width  float64
+	height float64
+	img    *ebiten.Image
+}
+
+// hudText returns the text cached under key, rendered again if s or size changed.
+func (g *Game) hudText(key, s string, size float64) *hudText {
+	h, ok := g.hud[key]
+	if !ok {
+		h = &hudText{}
+		g.hud[key] = h
+	}
+	if h.img != nil && h.text == s && h.size == size {
+		return h
+	}
+	if h.img != nil {
+		h.img.Deallocate()
+	}
+	face := &text.GoTextFace{Source: g.assets.font, Size: size}
+	h.text, h.size = s, size
+	h.width, h.height = text.Measure(s, face, 0)
+	h.img = ebiten.NewImage(max(1, int(math.Ceil(h.width))), max(1, int(math.Ceil(h.height))))
+	// The text is rendered in white, so it can be drawn in any color.
+	text.Draw(h.img, s, face, &text.DrawOptions{})
+	return h
+}
+
+// draw draws the text in the color with its top left corner at x, y.
+func (h *hudText) draw(screen *ebiten.Image, x, y float64, clr color.Color) {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(x, y)
+	op.ColorScale.ScaleWithColor(clr)
+	screen.DrawImage(h.img, op)
+}
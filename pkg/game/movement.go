@@ -0,0 +1,80 @@
+package game
+
+// cornerTolerance is how many pixels before or after the middle of a tile a
+// player can already or still turn into a side lane.
+const cornerTolerance = 6
+
+// movePlayer moves the player speed pixels along the lanes of the map.
+// The direction the player wants to go is buffered until the lanes allow it,
+// and taken at the middle of every tile on the way, so turns work at any
+// speed. Players turning a little early or late snap onto the lane they turn
+// into, so they don't clip into the walls beside it.
+func (g *Game) movePlayer(p *PlayerSlot, speed int) {
+	x, y := p.X, p.Y
+	for remaining := speed; remaining > 0; {
+		if dx, dy := sign(p.NextVx), sign(p.NextVy); (dx != 0 || dy != 0) && g.canMove(x, y, dx, dy) {
+			p.CurrentVx, p.CurrentVy = p.NextVx, p.NextVy
+			p.CurrentAnimation = p.NextAnimation
+			p.NextVx, p.NextVy = 0, 0
+			if dx != 0 {
+				y -= laneOffset(y)
+			} else {
+				x -= laneOffset(x)
+			}
+		}
+		dx, dy := sign(p.CurrentVx), sign(p.CurrentVy)
+		if dx == 0 && dy == 0 || !g.canMove(x, y, dx, dy) {
+			break
+		}
+		// Stop at the middle of the next tile, to turn there if the player
+		// wants to.
+		step := remaining
+		if dx != 0 {
+			step = min(step, distanceToMiddle(x, dx))
+		} else {
+			step = min(step, distanceToMiddle(y, dy))
+		}
+		x += dx * step
+		y += dy * step
+		remaining -= step
+	}
+	p.SetPosition(x, y)
+}
+
+// canMove reports whether a player at x, y can move one pixel in the
+// direction dx, dy. Side lanes can only be entered close to their middle.
+func (g *Game) canMove(x, y, dx, dy int) bool {
+	ox, oy := laneOffset(x), laneOffset(y)
+	if dx != 0 && abs(oy) > cornerTolerance || dy != 0 && abs(ox) > cornerTolerance {
+		return false
+	}
+	// The middle of the current tile can always be reached.
+	if ox*dx < 0 || oy*dy < 0 {
+		return true
+	}
+	return g.isFloor(tile{(x+16)/32 + dx, (y+16)/32 + dy})
+}
+
+// laneOffset returns how far v is from the middle of the closest tile.
+func laneOffset(v int) int {
+	return v - (v+16)/32*32
+}
+
+// distanceToMiddle returns how far the middle of the next tile in direction d is.
+func distanceToMiddle(v, d int) int {
+	offset := laneOffset(v)
+	if offset*d < 0 {
+		return abs(offset)
+	}
+	return 32 - abs(offset)
+}
+
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,127 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/NautiluX/8bites/pkg/sprites"
+)
+
+// crossingMap has a corridor on row 2 with a lane branching off upwards at
+// column 5.
+func crossingMap() [mapHeight][mapWidth]int {
+	var tiles [mapHeight][mapWidth]int
+	for y := range mapHeight {
+		for x := range mapWidth {
+			corridor := y == 2 && x >= 1 && x <= 10
+			lane := x == 5 && y <= 2
+			if !corridor && !lane {
+				tiles[y][x] = 1
+			}
+		}
+	}
+	return tiles
+}
+
+// clipsIntoWall reports whether the player at x, y overlaps a wall tile.
+func (g *Game) clipsIntoWall(x, y int) bool {
+	for ty := y / 32; ty <= (y+31)/32; ty++ {
+		for tx := x / 32; tx <= (x+31)/32; tx++ {
+			if !g.isFloor(tile{tx, ty}) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestTurnIntoLane(t *testing.T) {
+	const laneX, corridorY = 5 * 32, 2 * 32
+	tests := []struct {
+		// dx is the direction the player walks along the corridor.
+		dx int
+		// offset is how far the player is from the middle of the lane when up
+		// is pressed.
+		offset int
+		// turns is whether the player turns into the lane, immediately or once
+		// they reach its middle.
+		turns bool
+	}{
+		{1, 0, true},
+		{1, -cornerTolerance, true},
+		{1, cornerTolerance, true},
+		{1, -3, true},
+		{1, 3, true},
+		// Before the lane, the turn is taken in its middle.
+		{1, -cornerTolerance - 1, true},
+		{1, -20, true},
+		// Past the lane, the turn is missed.
+		{1, cornerTolerance + 1, false},
+		{1, 20, false},
+		{-1, cornerTolerance, true},
+		{-1, -cornerTolerance, true},
+		{-1, cornerTolerance + 1, true},
+		{-1, -cornerTolerance - 1, false},
+	}
+	for _, speed := range []int{1, 2, 3} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("speed %d direction %d offset %d", speed, tt.dx, tt.offset), func(t *testing.T) {
+				g := &Game{mapTiles: crossingMap()}
+				p := &PlayerSlot{Player: &sprites.Player{}}
+				p.SetPosition(laneX+tt.offset, corridorY)
+				p.CurrentVx = tt.dx * speed
+				p.NextVy = -speed
+
+				turned := false
+				for tick := range 100 {
+					g.movePlayer(p, speed)
+					if g.clipsIntoWall(p.X, p.Y) {
+						t.Fatalf("player clips into a wall at %d,%d after %d ticks", p.X, p.Y, tick+1)
+					}
+					if p.CurrentVy != 0 {
+						turned = true
+					}
+					if turned && p.X != laneX {
+						t.Fatalf("player is at %d,%d after turning, want them on the lane at x %d", p.X, p.Y, laneX)
+					}
+				}
+				if turned != tt.turns {
+					t.Fatalf("player turned: %v, want %v", turned, tt.turns)
+				}
+				if turned && p.Y != 0 {
+					t.Errorf("player stopped at %d,%d, want them at the end of the lane at %d,0", p.X, p.Y, laneX)
+				}
+			})
+		}
+	}
+}
+
+func TestCanMove(t *testing.T) {
+	g := &Game{mapTiles: crossingMap()}
+	tests := []struct {
+		x, y, dx, dy int
+		want         bool
+	}{
+		{5 * 32, 2 * 32, 0, -1, true},
+		{5*32 + cornerTolerance, 2 * 32, 0, -1, true},
+		{5*32 - cornerTolerance, 2 * 32, 0, -1, true},
+		{5*32 + cornerTolerance + 1, 2 * 32, 0, -1, false},
+		{5*32 - cornerTolerance - 1, 2 * 32, 0, -1, false},
+		// No lane branches off downwards.
+		{5 * 32, 2 * 32, 0, 1, false},
+		{4 * 32, 2 * 32, 0, -1, false},
+		// The middle of the current tile can always be reached.
+		{5*32 + 3, 2 * 32, -1, 0, true},
+		{10*32 + 3, 2 * 32, -1, 0, true},
+		// The corridor ends at column 10.
+		{10 * 32, 2 * 32, 1, 0, false},
+		{10 * 32, 2 * 32, -1, 0, true},
+		// Off the corridor the player can't move sideways.
+		{5 * 32, 32 + 7, 1, 0, false},
+	}
+	for _, tt := range tests {
+		if got := g.canMove(tt.x, tt.y, tt.dx, tt.dy); got != tt.want {
+			t.Errorf("canMove(%d, %d, %d, %d) = %v, want %v", tt.x, tt.y, tt.dx, tt.dy, got, tt.want)
+		}
+	}
+}
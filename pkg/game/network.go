@@ -0,0 +1,92 @@
+package game
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"log"
+
+	"github.com/NautiluX/8bites/pkg/netplay"
+)
+
+// hashInterval is the number of ticks between checks whether both peers of a
+// network game are still in sync.
+const hashInterval = 60
+
+// readNetworkInputs sends the local input to the other peer and returns the
+// inputs of both players once they are known.
+func (g *Game) readNetworkInputs() ([]Input, bool) {
+	inputs, ok, err := g.session.Step(uint8(controlsSingle.Read()))
+	if err != nil {
+		g.endNetworkGame(err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	return []Input{Input(inputs[0]), Input(inputs[1])}, true
+}
+
+// checkSync compares the state of the game with the other peer from time to time.
+func (g *Game) checkSync() {
+	tick := g.session.Tick()
+	if tick%hashInterval != 0 {
+		return
+	}
+	err := g.session.ReportHash(tick, g.stateHash())
+	if err != nil {
+		g.endNetworkGame(err)
+	}
+}
+
+// endNetworkGame stops a network game after the connection broke or the games
+// got out of sync. The players can continue with a local game afterwards.
+func (g *Game) endNetworkGame(err error) {
+	log.Printf("Network game ended: %v", err)
+	g.session.Close()
+	g.session = nil
+
+	g.title.Visible = true
+	g.title.StartTime = g.now()
+	g.title.WordsVisible = 0
+	g.title.Text = "CONNECTION LOST!"
+	if errors.Is(err, netplay.ErrDesync) {
+		g.title.Text = "OUT OF SYNC!"
+	}
+	g.Ended = true
+	g.Lost = true
+}
+
+// stateHash returns a hash of everything the simulation depends on.
+func (g *Game) stateHash() uint64 {
+	var state []int64
+	add := func(values ...int) {
+		for _, v := range values {
+			state = append(state, int64(v))
+		}
+	}
+	boolToInt := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	add(g.CurrentLevel, g.levelTicks, boolToInt(g.Ended), boolToInt(g.Lost))
+	for _, p := range g.players {
+		add(p.X, p.Y, p.CurrentVx, p.CurrentVy, p.NextVx, p.NextVy, p.Points, boolToInt(p.Dead))
+	}
+	for _, team := range g.teams {
+		add(len(team.eatenBites), team.goalStep)
+	}
+	for _, enemy := range g.enemies {
+		add(enemy.X, enemy.Y, enemy.CurrentVx, enemy.CurrentVy)
+	}
+	for _, bite := range g.activeBites {
+		add(int(bite.Id), bite.X, bite.Y, bite.TicksLeft)
+	}
+
+	h := fnv.New64a()
+	binary.Write(h, binary.LittleEndian, state)
+	return h.Sum64()
+}
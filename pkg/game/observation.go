@@ -0,0 +1,80 @@
+package game
+
+// Observation is a snapshot of the game state for agents playing the game,
+// like the bot or external AIs. Positions are in pixels, tiles are 32x32.
+type Observation struct {
+	Tiles      [][]int         `json:"tiles"`
+	Players    []PlayerState   `json:"players"`
+	Enemies    []EntityState   `json:"enemies"`
+	Bites      []EntityState   `json:"bites"`
+	EatenBites [][]string      `json:"eaten_bites"`
+	Level      int             `json:"level"`
+	Ended      bool            `json:"ended"`
+	Lost       bool            `json:"lost"`
+	Goal       ObservationGoal `json:"goal"`
+}
+
+type PlayerState struct {
+	X      int  `json:"x"`
+	Y      int  `json:"y"`
+	Vx     int  `json:"vx"`
+	Vy     int  `json:"vy"`
+	Points int  `json:"points"`
+	Dead   bool `json:"dead"`
+}
+
+type EntityState struct {
+	Name string `json:"name,omitempty"`
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+	Vx   int    `json:"vx"`
+	Vy   int    `json:"vy"`
+	// TicksLeft is the remaining lifetime of a bite, 0 means forever.
+	TicksLeft int `json:"ticks_left,omitempty"`
+}
+
+type ObservationGoal struct {
+	Type     GoalType `json:"type"`
+	Progress []int    `json:"progress"`
+	Target   int      `json:"target"`
+}
+
+// Observe returns the current state of the game. After a level was won, it
+// is the state the level ended in, until the next level starts.
+func (g *Game) Observe() Observation {
+	level := g.CurrentLevel
+	if g.Ended && !g.Lost && !g.Completed {
+		// CurrentLevel already is the next level.
+		level--
+	}
+	goal := g.levels[level].Goal.withDefaults()
+	o := Observation{
+		Level: level,
+		Ended: g.Ended,
+		Lost:  g.Lost,
+		Goal:  ObservationGoal{Type: goal.Type},
+	}
+	for _, row := range g.mapTiles {
+		o.Tiles = append(o.Tiles, append([]int(nil), row[:]...))
+	}
+	for _, p := range g.players {
+		o.Players = append(o.Players, PlayerState{X: p.X, Y: p.Y, Vx: p.CurrentVx, Vy: p.CurrentVy, Points: p.Points, Dead: p.Dead})
+	}
+	for _, enemy := range g.enemies {
+		o.Enemies = append(o.Enemies, EntityState{Name: "slime", X: enemy.X, Y: enemy.Y, Vx: enemy.CurrentVx, Vy: enemy.CurrentVy})
+	}
+	for _, bite := range g.activeBites {
+		o.Bites = append(o.Bites, EntityState{Name: g.assets.biteNames[bite.Id], X: bite.X, Y: bite.Y, TicksLeft: bite.TicksLeft})
+	}
+	for _, team := range g.teams {
+		eaten := []string{}
+		for _, bite := range team.eatenBites {
+			eaten = append(eaten, g.assets.biteNames[bite.Id])
+		}
+		o.EatenBites = append(o.EatenBites, eaten)
+		progress, target := g.progressTowards(goal, team)
+		o.Goal.Progress = append(o.Goal.Progress, progress)
+		o.Goal.Target = target
+	}
+	return o
+}
@@ -0,0 +1,54 @@
+package game
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestObserveFinishedLevel(t *testing.T) {
+	a, err := LoadAssets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGame(Config{Mode: ModeSingle, Assets: a, Seed: 1, Headless: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.levels) < 2 || g.levels[0].Tiles == g.levels[1].Tiles {
+		t.Fatal("the test needs two levels with different maps")
+	}
+	g.levels[0].Goal = Goal{Type: GoalDistinct, Count: 1}
+	g.levels[1].Goal = Goal{Type: GoalScore, Score: 500}
+	start := g.Observe()
+
+	// The first bite eaten wins the level.
+	team := g.teams[0]
+	team.eatenBites = append(team.eatenBites, g.activeBites[0].CharacterSprite)
+	err = g.Tick([]Input{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Ended || g.Lost || g.CurrentLevel != 1 {
+		t.Fatalf("level wasn't won: ended %v, lost %v, level %d", g.Ended, g.Lost, g.CurrentLevel)
+	}
+
+	o := g.Observe()
+	if o.Level != 0 {
+		t.Errorf("observed level is %d, want 0", o.Level)
+	}
+	if o.Goal.Type != GoalDistinct || o.Goal.Target != 1 || !slices.Equal(o.Goal.Progress, []int{1}) {
+		t.Errorf("observed goal is %+v, want the distinct goal of level 0 reached", o.Goal)
+	}
+	if !slices.EqualFunc(o.Tiles, start.Tiles, slices.Equal) {
+		t.Error("observed map isn't the map of level 0")
+	}
+
+	err = g.Tick([]Input{InputRestart})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o = g.Observe()
+	if o.Level != 1 || o.Goal.Type != GoalScore || o.Goal.Target != 500 {
+		t.Errorf("observed level %d with goal %+v after the next level started, want level 1 with its score goal", o.Level, o.Goal)
+	}
+}
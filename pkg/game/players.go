@@ -0,0 +1,202 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/NautiluX/8bites/pkg/sprites"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type GameMode string
+
+const (
+	ModeSingle GameMode = "single"
+	// ModeCoop lets two players collect bites together, with separate scores.
+	ModeCoop GameMode = "coop"
+	// ModeVersus lets two players race each other to reach the goal first.
+	ModeVersus GameMode = "versus"
+)
+
+// Controls binds a player to a set of keys and optionally a gamepad.
+type Controls struct {
+	Up    []ebiten.Key
+	Down  []ebiten.Key
+	Left  []ebiten.Key
+	Right []ebiten.Key
+	// Gamepad is the index of the connected gamepad steering the player, -1 for none.
+	Gamepad int
+}
+
+var (
+	controlsSingle = Controls{
+		Up:      []ebiten.Key{ebiten.KeyArrowUp, ebiten.KeyW},
+		Down:    []ebiten.Key{ebiten.KeyArrowDown, ebiten.KeyS},
+		Left:    []ebiten.Key{ebiten.KeyArrowLeft, ebiten.KeyA},
+		Right:   []ebiten.Key{ebiten.KeyArrowRight, ebiten.KeyD},
+		Gamepad: 0,
+	}
+	controlsPlayer1 = Controls{
+		Up:      []ebiten.Key{ebiten.KeyW},
+		Down:    []ebiten.Key{ebiten.KeyS},
+		Left:    []ebiten.Key{ebiten.KeyA},
+		Right:   []ebiten.Key{ebiten.KeyD},
+		Gamepad: 0,
+	}
+	controlsPlayer2 = Controls{
+		Up:      []ebiten.Key{ebiten.KeyArrowUp},
+		Down:    []ebiten.Key{ebiten.KeyArrowDown},
+		Left:    []ebiten.Key{ebiten.KeyArrowLeft},
+		Right:   []ebiten.Key{ebiten.KeyArrowRight},
+		Gamepad: 1,
+	}
+)
+
+func (c Controls) pressed(keys []ebiten.Key, button ebiten.StandardGamepadButton) bool {
+	for _, key := range keys {
+		if ebiten.IsKeyPressed(key) {
+			return true
+		}
+	}
+	if c.Gamepad < 0 {
+		return false
+	}
+	gamepads := ebiten.AppendGamepadIDs(nil)
+	if c.Gamepad >= len(gamepads) {
+		return false
+	}
+	return ebiten.IsStandardGamepadButtonPressed(gamepads[c.Gamepad], button)
+}
+
+// Input is the state of the controls of a player during one tick. Inputs are
+// all the simulation reads, so two games with the same seed and inputs stay
+// in sync.
+type Input uint8
+
+const (
+	InputUp Input = 1 << iota
+	InputDown
+	InputLeft
+	InputRight
+	// InputRestart starts the next game after the current one ended.
+	InputRestart
+)
+
+// Read returns the current input of the player.
+func (c Controls) Read() Input {
+	var input Input
+	if c.pressed(c.Up, ebiten.StandardGamepadButtonLeftTop) {
+		input |= InputUp
+	}
+	if c.pressed(c.Down, ebiten.StandardGamepadButtonLeftBottom) {
+		input |= InputDown
+	}
+	if c.pressed(c.Left, ebiten.StandardGamepadButtonLeftLeft) {
+		input |= InputLeft
+	}
+	if c.pressed(c.Right, ebiten.StandardGamepadButtonLeftRight) {
+		input |= InputRight
+	}
+	if c.pressed([]ebiten.Key{ebiten.KeyR, ebiten.KeySpace}, ebiten.StandardGamepadButtonCenterRight) {
+		input |= InputRestart
+	}
+	return input
+}
+
+// Team is a group of players sharing their bite collection and goal progress.
+type Team struct {
+	eatenBites []sprites.CharacterSprite
+	// goalStep is the number of bites eaten in the order required by the goal.
+	goalStep         int
+	levelStartPoints int
+	// duplicates counts the bites eaten again in the level.
+	duplicates int
+	players    []*PlayerSlot
+}
+
+func (t *Team) hasBiteBeenEaten(bite *sprites.CharacterSprite) bool {
+	for _, eatenBite := range t.eatenBites {
+		if bite.Id == eatenBite.Id {
+			return true
+		}
+	}
+	return false
+}
+
+// Points returns the sum of the points of all players in the team.
+func (t *Team) Points() int {
+	points := 0
+	for _, p := range t.players {
+		points += p.Points
+	}
+	return points
+}
+
+// reset prepares the team for a new level.
+func (t *Team) reset() {
+	t.eatenBites = []sprites.CharacterSprite{}
+	t.goalStep = 0
+	t.duplicates = 0
+	t.levelStartPoints = t.Points()
+}
+
+// PlayerSlot is a player taking part in the game.
+type PlayerSlot struct {
+	*sprites.Player
+	Number   int
+	Controls Controls
+	Team     *Team
+	Tint     color.RGBA
+	Dead     bool
+	// DeathCause tells what caught the player.
+	DeathCause string
+	// Bot players are steered by the built-in AI instead of the controls.
+	Bot bool
+	// input is what the player pressed in the current tick.
+	input Input
+}
+
+// newPlayers creates the players and teams for the game mode.
+func (g *Game) newPlayers() ([]*PlayerSlot, []*Team, error) {
+	newSlot := func(number int, controls Controls, tint color.RGBA, team *Team) *PlayerSlot {
+		p := &PlayerSlot{
+			Player:   sprites.NewPlayerSprite(g.playerImage, 32, 32, g.playerAnimations),
+			Number:   number,
+			Controls: controls,
+			Team:     team,
+			Tint:     tint,
+		}
+		team.players = append(team.players, p)
+		return p
+	}
+
+	white := color.RGBA{255, 255, 255, 255}
+	cyan := color.RGBA{110, 255, 255, 255}
+	switch g.Mode {
+	case ModeSingle:
+		team := &Team{}
+		return []*PlayerSlot{newSlot(1, controlsSingle, white, team)}, []*Team{team}, nil
+	case ModeCoop:
+		team := &Team{}
+		p1 := newSlot(1, controlsPlayer1, white, team)
+		p2 := newSlot(2, controlsPlayer2, cyan, team)
+		return []*PlayerSlot{p1, p2}, []*Team{team}, nil
+	case ModeVersus:
+		team1, team2 := &Team{}, &Team{}
+		p1 := newSlot(1, controlsPlayer1, white, team1)
+		p2 := newSlot(2, controlsPlayer2, cyan, team2)
+		return []*PlayerSlot{p1, p2}, []*Team{team1, team2}, nil
+	}
+	return nil, nil, fmt.Errorf("unknown game mode %q", g.Mode)
+}
+
+// alivePlayers returns the players that haven't been caught by an enemy.
+func (g *Game) alivePlayers() []*PlayerSlot {
+	var alive []*PlayerSlot
+	for _, p := range g.players {
+		if !p.Dead {
+			alive = append(alive, p)
+		}
+	}
+	return alive
+}
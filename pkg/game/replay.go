@@ -0,0 +1,114 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ReplayVersion is increased whenever a change to the game makes older replays
+// play out differently.
+const ReplayVersion = 4
+
+// maxReplayTicks limits the length of replays that are simulated, to one hour.
+const maxReplayTicks = 60 * 60 * 60
+
+// Replay records a game from its start to its end, so it can be simulated again.
+type Replay struct {
+	Version int
+	Seed    uint64
+	Mode    GameMode
+	Players int
+	// Inputs holds the inputs of all players, tick by tick.
+	Inputs []Input
+}
+
+// Ticks returns the number of recorded ticks.
+func (r *Replay) Ticks() int {
+	if r.Players == 0 {
+		return 0
+	}
+	return len(r.Inputs) / r.Players
+}
+
+// LastReplay returns the replay of the last finished game, or nil if no game
+// was finished yet.
+func (g *Game) LastReplay() *Replay {
+	return g.lastReplay
+}
+
+// Finished reports whether the game is over, because all players were caught
+// or the last level was completed.
+func (g *Game) Finished() bool {
+	return g.Ended && (g.Lost || g.Completed)
+}
+
+// Players returns all players taking part in the game.
+func (g *Game) Players() []*PlayerSlot {
+	return g.players
+}
+
+// LevelTicks returns the number of ticks since the current level started.
+func (g *Game) LevelTicks() int {
+	return g.levelTicks
+}
+
+// Points returns the points of all players.
+func (g *Game) Points() []int {
+	points := make([]int, len(g.players))
+	for i, p := range g.players {
+		points[i] = p.Points
+	}
+	return points
+}
+
+// startReplay begins the recording of a new game.
+func (g *Game) startReplay(seed uint64) {
+	g.replay = Replay{
+		Version: ReplayVersion,
+		Seed:    seed,
+		Mode:    g.Mode,
+		Players: len(g.players),
+	}
+}
+
+// recordInputs adds the inputs of a tick to the replay.
+func (g *Game) recordInputs(inputs []Input) {
+	g.replay.Inputs = append(g.replay.Inputs, inputs...)
+}
+
+// VerifyReplay simulates a replay without any audio and returns the points
+// of all players at its end. It fails if the game doesn't end exactly with
+// the last tick of the replay. The game plays with the given assets, or
+// loads its own if they are nil.
+func VerifyReplay(a *Assets, r Replay) ([]int, error) {
+	if r.Version != ReplayVersion {
+		return nil, fmt.Errorf("replay version %d is not supported, expected %d", r.Version, ReplayVersion)
+	}
+	if r.Players <= 0 || len(r.Inputs)%r.Players != 0 {
+		return nil, errors.New("replay inputs don't match the number of players")
+	}
+	if r.Ticks() > maxReplayTicks {
+		return nil, errors.New("replay is too long")
+	}
+
+	g, err := NewGame(Config{Mode: r.Mode, Seed: r.Seed, Headless: true, Assets: a})
+	if err != nil {
+		return nil, err
+	}
+	if len(g.players) != r.Players {
+		return nil, fmt.Errorf("replay has %d players, mode %s has %d", r.Players, r.Mode, len(g.players))
+	}
+	for t := range r.Ticks() {
+		if g.Finished() {
+			return nil, fmt.Errorf("game ended before the end of the replay at tick %d", t)
+		}
+		err := g.Tick(r.Inputs[t*r.Players : (t+1)*r.Players])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !g.Finished() {
+		return nil, errors.New("game didn't end with the replay")
+	}
+	return g.Points(), nil
+}
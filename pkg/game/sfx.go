@@ -0,0 +1,117 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/NautiluX/8bites/pkg/sound"
+)
+
+// Sound effects are played for these events. Levels can override them by name.
+const (
+	SfxBite          = "bite"
+	SfxDuplicate     = "duplicate"
+	SfxSpawn         = "spawn"
+	SfxIntro         = "intro"
+	SfxLevelComplete = "level_complete"
+	SfxGameComplete  = "game_complete"
+	SfxGameOver      = "game_over"
+	SfxMenu          = "menu"
+)
+
+// SoundEffect is the sound played for an event. No sound is played if Sound is empty.
+type SoundEffect struct {
+	Sound string
+	// MaxVoices is how many times the sound plays at once, unlimited if 0.
+	MaxVoices int
+	// PitchVariation randomly changes the pitch by up to this fraction.
+	PitchVariation float64
+}
+
+var defaultSfx = map[string]SoundEffect{
+	SfxBite:          {Sound: "bite", MaxVoices: 2, PitchVariation: 0.05},
+	SfxDuplicate:     {Sound: "duplicate", MaxVoices: 2, PitchVariation: 0.15},
+	SfxSpawn:         {Sound: "spawn", MaxVoices: 1, PitchVariation: 0.1},
+	SfxIntro:         {Sound: "intro", MaxVoices: 1},
+	SfxLevelComplete: {Sound: "levelcomplete", MaxVoices: 1},
+	SfxGameComplete:  {Sound: "gamecompleted", MaxVoices: 1},
+	SfxGameOver:      {Sound: "gameover", MaxVoices: 1},
+	SfxMenu:          {Sound: "menu", MaxVoices: 1},
+}
+
+// soundEffectFor returns the sound effect of the level for the event.
+func (g *Game) soundEffectFor(level int, event string) SoundEffect {
+	if sfx, ok := g.levels[level].Sfx[event]; ok {
+		return sfx
+	}
+	return defaultSfx[event]
+}
+
+// playSfx plays the sound effect of the current level for the event.
+func (g *Game) playSfx(event string) {
+	g.playLevelSfx(g.CurrentLevel, event)
+}
+
+// playLevelSfx plays the sound effect of the level for the event, if the game
+// has audio. Events of a level that was just completed are played with its
+// sound effects, although the current level already is the next one.
+func (g *Game) playLevelSfx(level int, event string) {
+	sfx := g.soundEffectFor(level, event)
+	if g.audio == nil || sfx.Sound == "" {
+		return
+	}
+	err := g.audio.PlaySfx(sfx.Sound, sound.Voice{MaxVoices: sfx.MaxVoices, PitchVariation: sfx.PitchVariation})
+	if err != nil {
+		g.reportAudioError(fmt.Errorf("failed to play %s sfx: %w", event, err))
+	}
+}
+
+// subscribeAudio plays the sound effects of the events, and fades out the
+// music when the game is lost. Failing sounds are reported, but don't stop the game.
+func (g *Game) subscribeAudio() {
+	Subscribe(&g.events, func(e LevelStarted) error {
+		g.playLevelSfx(e.Level, SfxIntro)
+		return nil
+	})
+	Subscribe(&g.events, func(BiteEaten) error {
+		g.playSfx(SfxBite)
+		return nil
+	})
+	Subscribe(&g.events, func(DuplicateEaten) error {
+		g.playSfx(SfxDuplicate)
+		return nil
+	})
+	Subscribe(&g.events, func(e EnemySpawned) error {
+		if !e.AtLevelStart {
+			// The intro is played instead.
+			g.playSfx(SfxSpawn)
+		}
+		return nil
+	})
+	Subscribe(&g.events, func(e LevelCompleted) error {
+		if e.Level+1 >= len(g.levels) {
+			// GameCompleted follows.
+			return nil
+		}
+		stinger := g.levels[e.Level].Soundtrack.Stinger
+		if stinger == "" || g.audio == nil {
+			g.playLevelSfx(e.Level, SfxLevelComplete)
+			return nil
+		}
+		err := g.audio.PlayStinger(stinger)
+		if err != nil {
+			g.reportAudioError(fmt.Errorf("failed to play stinger: %w", err))
+		}
+		return nil
+	})
+	Subscribe(&g.events, func(e GameCompleted) error {
+		g.playLevelSfx(e.Level, SfxGameComplete)
+		return nil
+	})
+	Subscribe(&g.events, func(GameOver) error {
+		if g.audio != nil {
+			g.audio.StopMusic(seconds(g.soundtrack().FadeOut))
+		}
+		g.playSfx(SfxGameOver)
+		return nil
+	})
+}
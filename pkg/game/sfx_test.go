@@ -0,0 +1,47 @@
+package game
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/NautiluX/8bites/assets"
+	"github.com/NautiluX/8bites/pkg/sound"
+	"github.com/NautiluX/8bites/pkg/sprites"
+)
+
+func TestLevelCompleteSfxOfFinishedLevel(t *testing.T) {
+	var loaded []string
+	audio, err := sound.NewManager(sound.NullBackend{}, func(name string) ([]byte, string, error) {
+		loaded = append(loaded, name)
+		return assets.GetSound(name)
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goal := Goal{Type: GoalDistinct, Count: 1}
+	g := &Game{
+		levels: []Level{
+			{Goal: goal, Sfx: map[string]SoundEffect{SfxLevelComplete: {Sound: "menu"}}},
+			{Goal: goal, Sfx: map[string]SoundEffect{SfxLevelComplete: {Sound: "spawn"}}},
+			{Goal: goal},
+		},
+		audio: audio,
+		teams: []*Team{{eatenBites: []sprites.CharacterSprite{{Id: sprites.SpriteIdApple}}}},
+	}
+	g.subscribeAudio()
+
+	for level, want := range []string{"menu", "spawn"} {
+		loaded = nil
+		g.CurrentLevel = level
+		err := g.checkGameEnd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.CurrentLevel != level+1 {
+			t.Fatalf("level %d wasn't completed", level)
+		}
+		if !slices.Equal(loaded, []string{want}) {
+			t.Errorf("completing level %d played %v, want %s", level, loaded, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package game
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Soundtrack is the music of a level. In the level config it is either the
+// name of the sound, or an object to change the timing of the music.
+type Soundtrack struct {
+	Name string
+	// FadeIn is how many seconds the music takes to start, 1 if unset.
+	FadeIn float64
+	// FadeOut is how many seconds the music takes to stop when the game is
+	// lost, 1.5 if unset.
+	FadeOut float64
+	// Crossfade is how many seconds the previous level's music takes to give
+	// way to this one, if they differ. 2 if unset.
+	Crossfade float64
+	// Duck is the volume of the music while the title is shown, 0.4 if
+	// unset. Set it to 1 to keep the music at full volume.
+	Duck float64
+	// DuckFade is how many seconds the music takes to duck, 0.3 if unset.
+	DuckFade float64
+	// Stinger is played instead of the level complete sound effect while the
+	// music is silenced. The music resumes afterwards.
+	Stinger string
+}
+
+func (s *Soundtrack) UnmarshalJSON(data []byte) error {
+	var name string
+	if json.Unmarshal(data, &name) == nil {
+		*s = Soundtrack{Name: name}
+		return nil
+	}
+	type plain Soundtrack
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// withDefaults returns the soundtrack with unset timings filled.
+func (s Soundtrack) withDefaults() Soundtrack {
+	if s.FadeIn == 0 {
+		s.FadeIn = 1
+	}
+	if s.FadeOut == 0 {
+		s.FadeOut = 1.5
+	}
+	if s.Crossfade == 0 {
+		s.Crossfade = 2
+	}
+	if s.Duck == 0 {
+		s.Duck = 0.4
+	}
+	if s.DuckFade == 0 {
+		s.DuckFade = 0.3
+	}
+	return s
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// soundtrack returns the soundtrack of the current level.
+func (g *Game) soundtrack() Soundtrack {
+	return g.levels[g.CurrentLevel].Soundtrack.withDefaults()
+}
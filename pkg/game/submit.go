@@ -0,0 +1,72 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+
+	"github.com/NautiluX/8bites/pkg/leaderboard"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// handleSubmit sends the score of the local player to the leaderboard once
+// the game is finished and the player presses Enter.
+func (g *Game) handleSubmit() {
+	select {
+	case status := <-g.submitResult:
+		g.submitStatus = status
+	default:
+	}
+
+	if g.leaderboardURL == "" || !g.Finished() || g.lastReplay == nil || g.submitStatus != "" {
+		return
+	}
+	if !inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		return
+	}
+
+	player := 0
+	if g.session != nil {
+		player = g.session.Player
+	}
+	replay, err := json.Marshal(g.lastReplay)
+	if err != nil {
+		g.submitStatus = "SUBMIT FAILED!"
+		return
+	}
+	name := g.playerName
+	if name == "" {
+		name = "anonymous"
+	}
+	submission := leaderboard.Submission{
+		Name:   name,
+		Player: player,
+		Points: g.players[player].Points,
+		Replay: replay,
+	}
+	g.submitStatus = "SUBMITTING..."
+	g.playSfx(SfxMenu)
+	g.submitResult = make(chan string, 1)
+	go func(result chan<- string) {
+		entry, err := leaderboard.Submit(g.leaderboardURL, submission)
+		if err != nil {
+			result <- "SUBMIT FAILED!"
+			return
+		}
+		result <- fmt.Sprintf("SUBMITTED %d POINTS!", entry.Points)
+	}(g.submitResult)
+}
+
+// drawSubmit shows below the title how to submit the score, or how the submission went.
+func (g *Game) drawSubmit(screen *ebiten.Image) {
+	if g.leaderboardURL == "" || !g.Finished() || g.lastReplay == nil {
+		return
+	}
+	status := g.submitStatus
+	if status == "" {
+		status = "[ENTER] SUBMIT SCORE"
+	}
+	t := g.hudText("submit", status, 16)
+	t.draw(screen, ScreenWidth/2-t.width/2, ScreenHeight/2+40, color.White)
+}
@@ -0,0 +1,35 @@
+package leaderboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Submit sends a score to the leaderboard server at url.
+func Submit(url string, s Submission) (Entry, error) {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return Entry{}, err
+	}
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(strings.TrimSuffix(url, "/")+"/scores", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return Entry{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return Entry{}, fmt.Errorf("leaderboard responded with %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var entry Entry
+	err = json.NewDecoder(resp.Body).Decode(&entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,119 @@
+// Package leaderboard implements a small HTTP API to collect high scores.
+// Scores are only accepted if the attached replay confirms them.
+package leaderboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maxSubmissionSize limits the size of submissions, replays of long games are
+// a few hundred kilobytes.
+const maxSubmissionSize = 8 << 20
+
+// ErrRejected is returned if a replay doesn't confirm the submitted points.
+var ErrRejected = errors.New("score rejected")
+
+// Submission is sent by the game to add a score to the leaderboard.
+type Submission struct {
+	Name string `json:"name"`
+	// Player is the index of the player in the replay the score belongs to.
+	Player int             `json:"player"`
+	Points int             `json:"points"`
+	Replay json.RawMessage `json:"replay"`
+}
+
+// Entry is a score on the leaderboard.
+type Entry struct {
+	Name   string    `json:"name"`
+	Points int       `json:"points"`
+	Time   time.Time `json:"time"`
+}
+
+// Verifier simulates the replay of a submission and returns the points the
+// player actually scored.
+type Verifier func(s Submission) (int, error)
+
+// Store keeps the entries of the leaderboard.
+type Store interface {
+	Add(e Entry) error
+	// Top returns the n best entries, best first.
+	Top(n int) ([]Entry, error)
+}
+
+// Server handles the leaderboard API:
+//
+//	POST /scores  adds a Submission, responds with the created Entry
+//	GET  /scores  lists the best entries
+type Server struct {
+	store  Store
+	verify Verifier
+	mux    *http.ServeMux
+	// Limit is the number of entries listed, 10 by default.
+	Limit int
+}
+
+func NewServer(store Store, verify Verifier) *Server {
+	s := &Server{
+		store:  store,
+		verify: verify,
+		mux:    http.NewServeMux(),
+		Limit:  10,
+	}
+	s.mux.HandleFunc("POST /scores", s.handleSubmit)
+	s.mux.HandleFunc("GET /scores", s.handleList)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	var submission Submission
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSubmissionSize)).Decode(&submission)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid submission: %v", err), http.StatusBadRequest)
+		return
+	}
+	if submission.Name == "" || len(submission.Name) > 32 {
+		http.Error(w, "name must have 1 to 32 characters", http.StatusBadRequest)
+		return
+	}
+
+	points, err := s.verify(submission)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%v: %v", ErrRejected, err), http.StatusUnprocessableEntity)
+		return
+	}
+	if points != submission.Points {
+		http.Error(w, fmt.Sprintf("%v: replay scores %d points, not %d", ErrRejected, points, submission.Points), http.StatusUnprocessableEntity)
+		return
+	}
+
+	entry := Entry{Name: submission.Name, Points: points, Time: time.Now().UTC()}
+	err = s.store.Add(entry)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to store score: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.store.Top(s.Limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read scores: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,84 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// verifyStub stands in for the game: the replay only holds the points it scores.
+func verifyStub(s Submission) (int, error) {
+	var replay struct{ Points int }
+	err := json.Unmarshal(s.Replay, &replay)
+	if err != nil {
+		return 0, err
+	}
+	if replay.Points < 0 {
+		return 0, errors.New("replay doesn't play out")
+	}
+	return replay.Points, nil
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	store, err := OpenFileStore(filepath.Join(t.TempDir(), "scores.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(NewServer(store, verifyStub))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSubmitValidReplay(t *testing.T) {
+	server := newTestServer(t)
+
+	entry, err := Submit(server.URL, Submission{Name: "alice", Points: 1200, Replay: json.RawMessage(`{"Points": 1200}`)})
+	if err != nil {
+		t.Fatalf("valid submission was rejected: %v", err)
+	}
+	if entry.Name != "alice" || entry.Points != 1200 {
+		t.Errorf("got entry %+v, want alice with 1200 points", entry)
+	}
+}
+
+func TestSubmitRejectsTamperedReplay(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		submission Submission
+	}{
+		{"more points than replayed", Submission{Name: "mallory", Points: 9999, Replay: json.RawMessage(`{"Points": 1200}`)}},
+		{"replay fails", Submission{Name: "mallory", Points: 1200, Replay: json.RawMessage(`{"Points": -1}`)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Submit(server.URL, tt.submission)
+			if err == nil {
+				t.Fatal("tampered submission was accepted")
+			}
+		})
+	}
+	_, err := Submit(server.URL, tests[0].submission)
+	if err == nil || !strings.Contains(err.Error(), ErrRejected.Error()) {
+		t.Errorf("got error %v, want %v", err, ErrRejected)
+	}
+
+	resp, err := server.Client().Get(server.URL + "/scores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var entries []Entry
+	err = json.NewDecoder(resp.Body).Decode(&entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("rejected submissions were stored: %+v", entries)
+	}
+}
@@ -0,0 +1,66 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/NautiluX/8bites/pkg/atomicfile"
+)
+
+// FileStore keeps all entries in memory and writes them to a JSON file on
+// every change.
+type FileStore struct {
+	path    string
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// OpenFileStore reads the entries from the file at path, if it exists.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &s.entries)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) Add(e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := append(slices.Clone(s.entries), e)
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = atomicfile.WriteFile(s.path, data, 0o644)
+	if err != nil {
+		return err
+	}
+	s.entries = entries
+	return nil
+}
+
+func (s *FileStore) Top(n int) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := slices.Clone(s.entries)
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return b.Points - a.Points
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries, nil
+}
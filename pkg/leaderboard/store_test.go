@@ -0,0 +1,47 @@
+package leaderboard
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.json")
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, e := range []Entry{
+		{Name: "alice", Points: 1200, Time: now},
+		{Name: "bob", Points: 3400, Time: now.Add(time.Minute)},
+		{Name: "carol", Points: 800, Time: now.Add(2 * time.Minute)},
+	} {
+		err := store.Add(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	top, err := reopened.Top(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Name: "bob", Points: 3400, Time: now.Add(time.Minute)},
+		{Name: "alice", Points: 1200, Time: now},
+	}
+	if len(top) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(top), len(want))
+	}
+	for i := range want {
+		if top[i].Name != want[i].Name || top[i].Points != want[i].Points || !top[i].Time.Equal(want[i].Time) {
+			t.Errorf("entry %d is %+v, want %+v", i, top[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Package netplay lets two game instances play together over TCP. Both
+// instances run the same deterministic simulation in lockstep: every tick is
+// only simulated once the inputs of both players for that tick are known.
+package netplay
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+)
+
+// Timeout is how long a peer may stay silent before the session is ended.
+const Timeout = 10 * time.Second
+
+var (
+	// ErrDisconnected is returned once the other peer left or the connection broke.
+	ErrDisconnected = errors.New("peer disconnected")
+	// ErrDesync is returned if the game states of both peers differ.
+	ErrDesync = errors.New("game states out of sync")
+)
+
+// Session is a lockstep connection to the other peer. Player 0 is the host,
+// player 1 the guest.
+type Session struct {
+	// Player is the number of the local player.
+	Player int
+	// Seed, Delay and Options are chosen by the host, so both peers start
+	// the same game.
+	Seed    uint64
+	Delay   int
+	Options string
+
+	conn net.Conn
+
+	// tick is the next tick to be simulated, sent the next tick local input
+	// has to be sent for.
+	tick        uint32
+	sent        uint32
+	localInputs map[uint32]uint8
+	localHashes map[uint32]uint64
+
+	mu           sync.Mutex
+	remoteInputs map[uint32]uint8
+	remoteHashes map[uint32]uint64
+	err          error
+}
+
+// Host waits for a guest to connect on addr. Inputs are delayed by the given
+// number of ticks, to hide the latency of the connection. The delay is sent
+// as a single byte, so it is at most 255.
+func Host(addr string, seed uint64, delay int, options string) (*Session, error) {
+	if delay < 0 || delay > math.MaxUint8 {
+		return nil, fmt.Errorf("delay %d is not between 0 and %d ticks", delay, math.MaxUint8)
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+	return accept(listener, seed, delay, options)
+}
+
+// accept waits for a guest to connect to the listener and sets up the session.
+func accept(listener net.Listener, seed uint64, delay int, options string) (*Session, error) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeMessage(conn, msgHello, hello{Version: ProtocolVersion, Seed: seed, Delay: uint8(delay), Options: options})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send hello: %w", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(Timeout))
+	guest, err := readHello(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read hello: %w", err)
+	}
+	if guest.Version != ProtocolVersion {
+		conn.Close()
+		return nil, fmt.Errorf("guest uses protocol version %d, expected %d", guest.Version, ProtocolVersion)
+	}
+	return newSession(conn, 0, seed, delay, options), nil
+}
+
+// Join connects to a host listening on addr.
+func Join(addr string) (*Session, error) {
+	conn, err := net.DialTimeout("tcp", addr, Timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Now().Add(Timeout))
+	host, err := readHello(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read hello: %w", err)
+	}
+	// The version is sent back in any case, so the host can report the mismatch as well.
+	err = writeMessage(conn, msgHello, hello{Version: ProtocolVersion})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send hello: %w", err)
+	}
+	if host.Version != ProtocolVersion {
+		conn.Close()
+		return nil, fmt.Errorf("host uses protocol version %d, expected %d", host.Version, ProtocolVersion)
+	}
+	return newSession(conn, 1, host.Seed, int(host.Delay), host.Options), nil
+}
+
+func newSession(conn net.Conn, player int, seed uint64, delay int, options string) *Session {
+	s := &Session{
+		Player:       player,
+		Seed:         seed,
+		Delay:        delay,
+		Options:      options,
+		conn:         conn,
+		sent:         uint32(delay),
+		localInputs:  map[uint32]uint8{},
+		localHashes:  map[uint32]uint64{},
+		remoteInputs: map[uint32]uint8{},
+		remoteHashes: map[uint32]uint64{},
+	}
+	go s.receive()
+	return s
+}
+
+func (s *Session) receive() {
+	for {
+		s.conn.SetReadDeadline(time.Now().Add(Timeout))
+		t, msg, err := readMessage(s.conn)
+		if err != nil {
+			s.fail(fmt.Errorf("%w: %v", ErrDisconnected, err))
+			return
+		}
+		s.mu.Lock()
+		switch m := msg.(type) {
+		case inputMessage:
+			s.remoteInputs[m.Tick] = m.Input
+		case hashMessage:
+			s.remoteHashes[m.Tick] = m.Hash
+		}
+		s.mu.Unlock()
+		if t == msgBye {
+			s.fail(ErrDisconnected)
+			return
+		}
+	}
+}
+
+func (s *Session) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// Err returns the error that ended the session, if any.
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Tick returns the number of the next tick to be simulated.
+func (s *Session) Tick() int {
+	return int(s.tick)
+}
+
+// Step sends the local input and returns the inputs of both players for the
+// next tick, indexed by player number. It returns false if the input of the
+// other peer hasn't arrived yet, in which case the tick must not be simulated.
+func (s *Session) Step(local uint8) ([2]uint8, bool, error) {
+	var inputs [2]uint8
+	err := s.Err()
+	if err != nil {
+		return inputs, false, err
+	}
+
+	// The local input is scheduled Delay ticks ahead. Ticks before Delay have
+	// no input on both sides.
+	target := s.tick + uint32(s.Delay)
+	if s.sent <= target {
+		s.localInputs[target] = local
+		err := writeMessage(s.conn, msgInput, inputMessage{Tick: target, Input: local})
+		if err != nil {
+			s.fail(fmt.Errorf("%w: %v", ErrDisconnected, err))
+			return inputs, false, s.Err()
+		}
+		s.sent = target + 1
+	}
+
+	remote, ok := s.remoteInput(s.tick)
+	if !ok {
+		return inputs, false, nil
+	}
+	inputs[s.Player] = s.localInputs[s.tick]
+	inputs[1-s.Player] = remote
+	delete(s.localInputs, s.tick)
+	s.tick++
+	return inputs, true, nil
+}
+
+func (s *Session) remoteInput(tick uint32) (uint8, bool) {
+	if tick < uint32(s.Delay) {
+		return 0, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	input, ok := s.remoteInputs[tick]
+	delete(s.remoteInputs, tick)
+	return input, ok
+}
+
+// ReportHash sends a hash of the local game state after the given tick, and
+// compares it with the hashes the other peer sent. A mismatch ends the session
+// with ErrDesync.
+func (s *Session) ReportHash(tick int, hash uint64) error {
+	err := writeMessage(s.conn, msgHash, hashMessage{Tick: uint32(tick), Hash: hash})
+	if err != nil {
+		s.fail(fmt.Errorf("%w: %v", ErrDisconnected, err))
+		return s.Err()
+	}
+	s.localHashes[uint32(tick)] = hash
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for t, local := range s.localHashes {
+		remote, ok := s.remoteHashes[t]
+		if !ok {
+			continue
+		}
+		delete(s.localHashes, t)
+		delete(s.remoteHashes, t)
+		if local != remote && s.err == nil {
+			s.err = fmt.Errorf("%w at tick %d", ErrDesync, t)
+		}
+	}
+	return s.err
+}
+
+// Close tells the other peer that the session ends and closes the connection.
+func (s *Session) Close() error {
+	writeMessage(s.conn, msgBye, nil)
+	s.fail(ErrDisconnected)
+	return s.conn.Close()
+}
@@ -0,0 +1,197 @@
+package netplay
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// connect sets up a session between a host and a guest on the loopback interface.
+func connect(t *testing.T, delay int) (*Session, *Session) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	type result struct {
+		s   *Session
+		err error
+	}
+	hosted := make(chan result)
+	go func() {
+		s, err := accept(listener, 1234, delay, "versus")
+		hosted <- result{s, err}
+	}()
+	guest, err := Join(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := <-hosted
+	if host.err != nil {
+		t.Fatal(host.err)
+	}
+	t.Cleanup(func() {
+		host.s.Close()
+		guest.Close()
+	})
+	return host.s, guest
+}
+
+// waitFor calls f until it returns true, and fails the test after a second.
+func waitFor(t *testing.T, what string, f func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestJoinReceivesHostSettings(t *testing.T) {
+	host, guest := connect(t, 5)
+	if host.Player != 0 || guest.Player != 1 {
+		t.Errorf("players are %d and %d, want 0 and 1", host.Player, guest.Player)
+	}
+	if guest.Seed != 1234 || guest.Delay != 5 || guest.Options != "versus" {
+		t.Errorf("guest got seed %d, delay %d and options %q, want 1234, 5 and versus", guest.Seed, guest.Delay, guest.Options)
+	}
+}
+
+func TestHostRejectsDelay(t *testing.T) {
+	for _, delay := range []int{-1, 256} {
+		_, err := Host("127.0.0.1:0", 1, delay, "")
+		if err == nil {
+			t.Errorf("delay %d was accepted", delay)
+		}
+	}
+}
+
+func TestStep(t *testing.T) {
+	const delay, ticks = 2, 20
+	host, guest := connect(t, delay)
+
+	// Each peer presses its own number plus the tick, so the inputs show who
+	// sent them and for which tick.
+	var hostInputs, guestInputs [][2]uint8
+	step := func(s *Session, inputs *[][2]uint8) {
+		for len(*inputs) < ticks {
+			tick := s.Tick()
+			in, ok, err := s.Step(uint8(10*s.Player + tick))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !ok {
+				if s.Tick() != tick {
+					t.Errorf("tick advanced from %d to %d without inputs", tick, s.Tick())
+				}
+				time.Sleep(time.Millisecond)
+				continue
+			}
+			*inputs = append(*inputs, in)
+		}
+	}
+	done := make(chan bool)
+	go func() {
+		step(guest, &guestInputs)
+		done <- true
+	}()
+	step(host, &hostInputs)
+	<-done
+
+	for tick := range ticks {
+		want := [2]uint8{}
+		if tick >= delay {
+			// The input of a tick was pressed Delay ticks earlier.
+			want = [2]uint8{uint8(tick - delay), uint8(10 + tick - delay)}
+		}
+		if hostInputs[tick] != want || guestInputs[tick] != want {
+			t.Errorf("inputs of tick %d are %v on the host and %v on the guest, want %v", tick, hostInputs[tick], guestInputs[tick], want)
+		}
+	}
+}
+
+func TestStepWaitsForRemoteInput(t *testing.T) {
+	host, guest := connect(t, 0)
+	_, ok, err := host.Step(1)
+	if err != nil || ok {
+		t.Fatalf("step without the guest's input returned %v, %v", ok, err)
+	}
+	if _, _, err := guest.Step(2); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the guest's input", func() bool {
+		inputs, ok, err := host.Step(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok && inputs != [2]uint8{1, 2} {
+			t.Fatalf("inputs are %v, want [1 2]", inputs)
+		}
+		return ok
+	})
+}
+
+func TestReportHashDesync(t *testing.T) {
+	host, guest := connect(t, 0)
+	report := func(s *Session, tick int, hash uint64) error {
+		t.Helper()
+		err := s.ReportHash(tick, hash)
+		if err != nil && !errors.Is(err, ErrDesync) {
+			t.Fatal(err)
+		}
+		return err
+	}
+
+	// Hashes are compared once both are known, reporting a hash again
+	// compares those that arrived since.
+	for tick := range 4 {
+		report(guest, tick, uint64(tick))
+		report(host, tick, uint64(tick))
+	}
+	waitFor(t, "the hashes to be compared", func() bool {
+		return report(host, 3, 3) == nil && len(host.localHashes) == 0
+	})
+
+	report(host, 4, 1)
+	report(guest, 4, 2)
+	var err error
+	waitFor(t, "the desync", func() bool {
+		err = report(host, 5, 5)
+		return err != nil
+	})
+	if !strings.Contains(err.Error(), "at tick 4") {
+		t.Errorf("error is %q, want the desync at tick 4", err)
+	}
+	if _, _, err := host.Step(0); !errors.Is(err, ErrDesync) {
+		t.Errorf("step after the desync returned %v, want %v", err, ErrDesync)
+	}
+}
+
+func TestCloseDisconnects(t *testing.T) {
+	host, guest := connect(t, 0)
+	guest.Close()
+	if _, _, err := guest.Step(0); !errors.Is(err, ErrDisconnected) {
+		t.Errorf("step after closing returned %v, want %v", err, ErrDisconnected)
+	}
+	waitFor(t, "the host to notice", func() bool {
+		return errors.Is(host.Err(), ErrDisconnected)
+	})
+	if _, _, err := host.Step(0); !errors.Is(err, ErrDisconnected) {
+		t.Errorf("step of the host returned %v, want %v", err, ErrDisconnected)
+	}
+}
+
+func TestDroppedPeerDisconnects(t *testing.T) {
+	host, guest := connect(t, 0)
+	// The connection breaks without a bye message.
+	guest.conn.Close()
+	waitFor(t, "the host to notice", func() bool {
+		return errors.Is(host.Err(), ErrDisconnected)
+	})
+}
@@ -0,0 +1,120 @@
+package netplay
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ProtocolVersion is increased on every incompatible change of the messages.
+// Peers with different versions refuse to play with each other.
+const ProtocolVersion = 1
+
+type messageType uint8
+
+const (
+	msgHello messageType = iota + 1
+	msgInput
+	msgHash
+	msgBye
+)
+
+// hello is exchanged when a connection is set up. The host decides on the
+// seed, the input delay and the game options, the guest only sends its version.
+type hello struct {
+	Version uint16
+	Seed    uint64
+	Delay   uint8
+	Options string
+}
+
+// inputMessage carries the input of the sending peer for a tick.
+type inputMessage struct {
+	Tick  uint32
+	Input uint8
+}
+
+// hashMessage carries a hash of the game state of the sending peer after a tick.
+type hashMessage struct {
+	Tick uint32
+	Hash uint64
+}
+
+func writeMessage(w io.Writer, t messageType, msg any) error {
+	// The length of the options is sent as a single byte.
+	if h, ok := msg.(hello); ok && len(h.Options) > math.MaxUint8 {
+		return fmt.Errorf("options are %d bytes long, at most %d are supported", len(h.Options), math.MaxUint8)
+	}
+	err := binary.Write(w, binary.BigEndian, t)
+	if err != nil {
+		return err
+	}
+	switch m := msg.(type) {
+	case nil:
+		return nil
+	case hello:
+		err := binary.Write(w, binary.BigEndian, struct {
+			Version uint16
+			Seed    uint64
+			Delay   uint8
+			Length  uint8
+		}{m.Version, m.Seed, m.Delay, uint8(len(m.Options))})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, m.Options)
+		return err
+	default:
+		return binary.Write(w, binary.BigEndian, msg)
+	}
+}
+
+func readHello(r io.Reader) (hello, error) {
+	var t messageType
+	err := binary.Read(r, binary.BigEndian, &t)
+	if err != nil {
+		return hello{}, err
+	}
+	if t != msgHello {
+		return hello{}, fmt.Errorf("expected hello, got message type %d", t)
+	}
+	var header struct {
+		Version uint16
+		Seed    uint64
+		Delay   uint8
+		Length  uint8
+	}
+	err = binary.Read(r, binary.BigEndian, &header)
+	if err != nil {
+		return hello{}, err
+	}
+	options := make([]byte, header.Length)
+	_, err = io.ReadFull(r, options)
+	if err != nil {
+		return hello{}, err
+	}
+	return hello{Version: header.Version, Seed: header.Seed, Delay: header.Delay, Options: string(options)}, nil
+}
+
+// readMessage reads the next message after the handshake.
+func readMessage(r io.Reader) (messageType, any, error) {
+	var t messageType
+	err := binary.Read(r, binary.BigEndian, &t)
+	if err != nil {
+		return 0, nil, err
+	}
+	switch t {
+	case msgInput:
+		var m inputMessage
+		err := binary.Read(r, binary.BigEndian, &m)
+		return t, m, err
+	case msgHash:
+		var m hashMessage
+		err := binary.Read(r, binary.BigEndian, &m)
+		return t, m, err
+	case msgBye:
+		return t, nil, nil
+	}
+	return 0, nil, fmt.Errorf("unknown message type %d", t)
+}
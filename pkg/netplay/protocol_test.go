@@ -0,0 +1,82 @@
+package netplay
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	sent := hello{Version: ProtocolVersion, Seed: 0xDEADBEEFCAFE, Delay: 255, Options: strings.Repeat("v", 255)}
+	if err := writeMessage(&buf, msgHello, sent); err != nil {
+		t.Fatal(err)
+	}
+	received, err := readHello(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received != sent {
+		t.Errorf("hello is %+v, want %+v", received, sent)
+	}
+
+	messages := []struct {
+		t   messageType
+		msg any
+	}{
+		{msgInput, inputMessage{Tick: 1<<32 - 1, Input: 0b1010}},
+		{msgHash, hashMessage{Tick: 42, Hash: 1<<64 - 1}},
+		{msgBye, nil},
+	}
+	for _, m := range messages {
+		if err := writeMessage(&buf, m.t, m.msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, m := range messages {
+		typ, msg, err := readMessage(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ != m.t || msg != m.msg {
+			t.Errorf("message is %d %+v, want %d %+v", typ, msg, m.t, m.msg)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Errorf("%d bytes are left after reading all messages", buf.Len())
+	}
+}
+
+func TestMalformedMessages(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeMessage(&buf, msgHello, hello{Options: strings.Repeat("v", 256)})
+	if err == nil {
+		t.Error("hello with too long options was written")
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"unknown type", []byte{99}},
+		{"truncated input", []byte{byte(msgInput), 0, 0}},
+		{"truncated hash", []byte{byte(msgHash), 0, 0, 0, 1, 0xFF}},
+		{"hello after the handshake", []byte{byte(msgHello)}},
+	}
+	for _, tt := range tests {
+		_, _, err := readMessage(bytes.NewReader(tt.data))
+		if err == nil {
+			t.Errorf("%s message was read", tt.name)
+		}
+	}
+
+	_, err = readHello(bytes.NewReader([]byte{byte(msgInput), 0, 0, 0, 1, 0}))
+	if err == nil {
+		t.Error("input was read as hello")
+	}
+	_, err = readHello(bytes.NewReader([]byte{byte(msgHello), 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 3, 10, 'v'}))
+	if err == nil {
+		t.Error("hello with truncated options was read")
+	}
+}
@@ -0,0 +1,170 @@
+// Package sfxr generates chiptune sound effects from a few parameters, in the
+// spirit of the sfxr tool.
+package sfxr
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+)
+
+// Waveform is the shape of the oscillator.
+type Waveform string
+
+const (
+	Square   Waveform = "square"
+	Triangle Waveform = "triangle"
+	Sawtooth Waveform = "sawtooth"
+	Noise    Waveform = "noise"
+)
+
+// maxLength caps the length of an effect in seconds, so a typo doesn't
+// generate minutes of sound.
+const maxLength = 10
+
+// Params define a sound effect. Times are in seconds, frequencies in Hz.
+type Params struct {
+	Wave Waveform
+	// Attack, Sustain and Decay shape the volume: it rises during Attack,
+	// holds during Sustain and falls during Decay.
+	Attack  float64
+	Sustain float64
+	Decay   float64
+	// Punch raises the volume at the start of Sustain by this fraction.
+	Punch float64
+
+	Frequency float64
+	// Slide changes the frequency by octaves per second, DeltaSlide changes
+	// the slide per second.
+	Slide      float64
+	DeltaSlide float64
+	// MinFrequency ends the effect once the frequency slides below it.
+	MinFrequency float64
+
+	// VibratoDepth is the fraction the frequency wobbles by, VibratoSpeed how
+	// often per second.
+	VibratoDepth float64
+	VibratoSpeed float64
+
+	// Arpeggio multiplies the frequency with its values in turn, each for
+	// ArpeggioSpeed.
+	Arpeggio      []float64
+	ArpeggioSpeed float64
+
+	// Duty is the fraction of a square wave that is high, 0.5 if unset.
+	// DutySweep changes it per second.
+	Duty      float64
+	DutySweep float64
+
+	// Volume is from 0 to 1, 0.5 if unset.
+	Volume float64
+}
+
+// Parse reads the parameters from JSON.
+func Parse(data []byte) (Params, error) {
+	var p Params
+	err := json.Unmarshal(data, &p)
+	if err != nil {
+		return Params{}, err
+	}
+	return p, p.validate()
+}
+
+func (p Params) validate() error {
+	switch p.Wave {
+	case Square, Triangle, Sawtooth, Noise:
+	default:
+		return fmt.Errorf("unknown wave %q", p.Wave)
+	}
+	if p.Frequency <= 0 {
+		return fmt.Errorf("Frequency has to be positive")
+	}
+	if p.Attack < 0 || p.Sustain < 0 || p.Decay < 0 || p.Attack+p.Sustain+p.Decay == 0 {
+		return fmt.Errorf("Attack, Sustain and Decay can't be negative, and one has to be set")
+	}
+	if len(p.Arpeggio) > 0 && p.ArpeggioSpeed <= 0 {
+		return fmt.Errorf("Arpeggio needs a positive ArpeggioSpeed")
+	}
+	return nil
+}
+
+// envelope returns the volume at time t, or false once the effect ended.
+func (p Params) envelope(t float64) (float64, bool) {
+	switch {
+	case t < p.Attack:
+		return t / p.Attack, true
+	case t < p.Attack+p.Sustain:
+		return 1 + p.Punch*(1-(t-p.Attack)/p.Sustain), true
+	case t < p.Attack+p.Sustain+p.Decay:
+		return 1 - (t-p.Attack-p.Sustain)/p.Decay, true
+	}
+	return 0, false
+}
+
+// Generate renders the effect as 16 bit stereo PCM at the sample rate.
+func Generate(p Params, sampleRate int) []byte {
+	duty := p.Duty
+	if duty == 0 {
+		duty = 0.5
+	}
+	volume := p.Volume
+	if volume == 0 {
+		volume = 0.5
+	}
+	// Noise is seeded the same way every time, so an effect always sounds the same.
+	rng := rand.New(rand.NewPCG(1, 2))
+	noise := rng.Float64()*2 - 1
+
+	dt := 1 / float64(sampleRate)
+	var pcm []byte
+	var phase, octaves float64
+	slide := p.Slide
+	for i := 0; i < maxLength*sampleRate; i++ {
+		t := float64(i) * dt
+		env, ok := p.envelope(t)
+		if !ok {
+			break
+		}
+
+		slide += p.DeltaSlide * dt
+		octaves += slide * dt
+		freq := p.Frequency * math.Exp2(octaves)
+		if p.MinFrequency > 0 && freq < p.MinFrequency {
+			break
+		}
+		if len(p.Arpeggio) > 0 {
+			freq *= p.Arpeggio[min(int(t/p.ArpeggioSpeed), len(p.Arpeggio)-1)]
+		}
+		if p.VibratoDepth > 0 {
+			freq *= 1 + p.VibratoDepth*math.Sin(2*math.Pi*p.VibratoSpeed*t)
+		}
+		duty = min(max(duty+p.DutySweep*dt, 0.05), 0.95)
+
+		phase += freq * dt
+		if phase >= 1 {
+			phase -= math.Floor(phase)
+			noise = rng.Float64()*2 - 1
+		}
+		var v float64
+		switch p.Wave {
+		case Square:
+			v = 1
+			if phase >= duty {
+				v = -1
+			}
+		case Triangle:
+			v = 4*math.Abs(phase-0.5) - 1
+		case Sawtooth:
+			v = 2*phase - 1
+		case Noise:
+			v = noise
+		}
+
+		s := int16(min(max(v*env*volume, -1), 1) * math.MaxInt16)
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(s))
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(s))
+	}
+	return pcm
+}
@@ -0,0 +1,111 @@
+package sound
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ebitengine/oto/v3"
+)
+
+// Backend creates the players of the sounds.
+type Backend interface {
+	// NewPlayer plays 16 bit stereo PCM read from src.
+	NewPlayer(src io.Reader) (Player, error)
+	// NewPlayerFromBytes plays 16 bit stereo PCM.
+	NewPlayerFromBytes(pcm []byte) Player
+	// Err returns the error that stopped the backend from playing, if any.
+	Err() error
+}
+
+// Player plays a sound, *oto.Player implements it.
+type Player interface {
+	Play()
+	Pause()
+	IsPlaying() bool
+	SetVolume(volume float64)
+	Close() error
+}
+
+// DeviceBackend plays the sounds on the sound device. It doesn't use an
+// Ebitengine audio context, as its errors end the game: errors of the device
+// are returned by Err instead, so the game can continue without sound.
+type DeviceBackend struct {
+	context *oto.Context
+}
+
+// readyTimeout is how long the sound device may take to get ready.
+const readyTimeout = 5 * time.Second
+
+// openDevice opens the sound device once, it can't be opened again.
+var openDevice = sync.OnceValues(func() (*DeviceBackend, error) {
+	context, ready, err := oto.NewContext(&oto.NewContextOptions{
+		SampleRate:   SampleRate,
+		ChannelCount: 2,
+		Format:       oto.FormatSignedInt16LE,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sound device: %w", err)
+	}
+	// Browsers only get ready once the player interacts with the page, the
+	// sounds played until then wait for it.
+	if runtime.GOOS != "js" {
+		select {
+		case <-ready:
+		case <-time.After(readyTimeout):
+			return nil, fmt.Errorf("failed to open sound device: not ready after %v", readyTimeout)
+		}
+		// Failing to open the device is only reported once it is ready.
+		err := context.Err()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open sound device: %w", err)
+		}
+	}
+	return &DeviceBackend{context: context}, nil
+})
+
+// NewDeviceBackend returns a backend for the sound device, opening it on
+// first use.
+func NewDeviceBackend() (*DeviceBackend, error) {
+	return openDevice()
+}
+
+func (b *DeviceBackend) NewPlayer(src io.Reader) (Player, error) {
+	return b.context.NewPlayer(src), nil
+}
+
+func (b *DeviceBackend) NewPlayerFromBytes(pcm []byte) Player {
+	return b.context.NewPlayer(bytes.NewReader(pcm))
+}
+
+func (b *DeviceBackend) Err() error {
+	return b.context.Err()
+}
+
+// NullBackend plays nothing. It's for machines without a sound device, and
+// for tests: sounds are still loaded and decoded.
+type NullBackend struct{}
+
+func (NullBackend) NewPlayer(io.Reader) (Player, error) {
+	return nullPlayer{}, nil
+}
+
+func (NullBackend) NewPlayerFromBytes([]byte) Player {
+	return nullPlayer{}
+}
+
+func (NullBackend) Err() error {
+	return nil
+}
+
+// nullPlayer finishes every sound right away.
+type nullPlayer struct{}
+
+func (nullPlayer) Play()             {}
+func (nullPlayer) Pause()            {}
+func (nullPlayer) IsPlaying() bool   { return false }
+func (nullPlayer) SetVolume(float64) {}
+func (nullPlayer) Close() error      { return nil }
@@ -0,0 +1,168 @@
+package sound
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/NautiluX/8bites/pkg/tracker"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+// stingerDuck is how fast the music fades out and back in around a stinger.
+const stingerDuck = 200 * time.Millisecond
+
+// fader moves a gain linearly towards its target.
+type fader struct {
+	gain   float64
+	target float64
+	// speed is the change of the gain per second.
+	speed float64
+}
+
+// fadeTo moves the gain to target within d, or at once if d is 0.
+func (f *fader) fadeTo(target float64, d time.Duration) {
+	f.target = target
+	if d <= 0 {
+		f.gain = target
+		return
+	}
+	f.speed = 1 / d.Seconds()
+}
+
+func (f *fader) update(dt time.Duration) {
+	step := f.speed * dt.Seconds()
+	if f.gain < f.target {
+		f.gain = min(f.target, f.gain+step)
+	} else {
+		f.gain = max(f.target, f.gain-step)
+	}
+}
+
+// track is a looping soundtrack.
+type track struct {
+	name   string
+	player Player
+	fader
+}
+
+func (t *track) fadingOut() bool {
+	return t.target == 0
+}
+
+// current returns the soundtrack that isn't fading out, if any.
+func (m *Manager) current() *track {
+	if len(m.music) == 0 || m.music[len(m.music)-1].fadingOut() {
+		return nil
+	}
+	return m.music[len(m.music)-1]
+}
+
+// MusicPlaying reports whether a soundtrack is playing, that isn't fading out.
+func (m *Manager) MusicPlaying() bool {
+	return m.current() != nil
+}
+
+// PlayMusic loops the sound on the music bus. It fades in within fade, while
+// the previous soundtrack fades out. The music keeps playing if it already is
+// the sound.
+func (m *Manager) PlayMusic(name string, fade time.Duration) error {
+	if current := m.current(); current != nil && current.name == name {
+		return nil
+	}
+	c, err := m.clip(name)
+	if err != nil {
+		return err
+	}
+	var loop io.Reader
+	if c.module != nil {
+		// Modules are synthesized while they play, so their loops sound seamless.
+		loop = tracker.NewStream(c.module, SampleRate, true)
+	} else {
+		loop = audio.NewInfiniteLoop(bytes.NewReader(c.pcm), int64(len(c.pcm)))
+	}
+	player, err := m.backend.NewPlayer(loop)
+	if err != nil {
+		return err
+	}
+	m.StopMusic(fade)
+	t := &track{name: name, player: player}
+	t.fadeTo(1, fade)
+	if fade > 0 {
+		t.gain = 0
+	}
+	m.music = append(m.music, t)
+	m.applyMusicVolume()
+	player.Play()
+	return nil
+}
+
+// StopMusic fades out the music within fade.
+func (m *Manager) StopMusic(fade time.Duration) {
+	for _, t := range m.music {
+		t.fadeTo(0, fade)
+	}
+	m.releaseMusic()
+}
+
+// Duck lowers the music to volume within fade, e.g. while something is
+// announced. Duck(1, fade) restores it.
+func (m *Manager) Duck(volume float64, fade time.Duration) {
+	if m.duck.target == volume {
+		return
+	}
+	m.duck.fadeTo(volume, fade)
+}
+
+// PlayStinger plays a short piece on the music bus, while the music is
+// silenced. The music resumes afterwards.
+func (m *Manager) PlayStinger(name string) error {
+	pcm, err := m.decode(name)
+	if err != nil {
+		return err
+	}
+	if m.stinger != nil {
+		m.stinger.Close()
+	}
+	m.stingerFade.fadeTo(0, stingerDuck)
+	m.stinger = m.backend.NewPlayerFromBytes(pcm)
+	m.stinger.SetVolume(m.volume(BusMusic))
+	m.stinger.Play()
+	return nil
+}
+
+func (m *Manager) updateMusic(dt time.Duration) {
+	if m.stinger != nil && !m.stinger.IsPlaying() {
+		m.stinger.Close()
+		m.stinger = nil
+		m.stingerFade.fadeTo(1, stingerDuck)
+	}
+	m.duck.update(dt)
+	m.stingerFade.update(dt)
+	for _, t := range m.music {
+		t.update(dt)
+	}
+	m.releaseMusic()
+	m.applyMusicVolume()
+}
+
+// releaseMusic closes the soundtracks that faded out.
+func (m *Manager) releaseMusic() {
+	m.music = slices.DeleteFunc(m.music, func(t *track) bool {
+		if !t.fadingOut() || t.gain > 0 {
+			return false
+		}
+		t.player.Close()
+		return true
+	})
+}
+
+func (m *Manager) applyMusicVolume() {
+	for _, t := range m.music {
+		t.player.SetVolume(m.volume(BusMusic) * t.gain * m.duck.gain * m.stingerFade.gain)
+	}
+	if m.stinger != nil {
+		m.stinger.SetVolume(m.volume(BusMusic))
+	}
+}
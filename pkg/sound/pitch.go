@@ -0,0 +1,32 @@
+package sound
+
+import "encoding/binary"
+
+// bytesPerFrame is the size of a stereo frame of 16 bit samples.
+const bytesPerFrame = 4
+
+// resample changes the pitch of the PCM data by playing it faster or slower.
+// A speed of 2 raises the pitch by an octave and halves the length. The data
+// is returned unchanged if the speed isn't positive.
+func resample(pcm []byte, speed float64) []byte {
+	if speed <= 0 {
+		return pcm
+	}
+	frames := len(pcm) / bytesPerFrame
+	outFrames := int(float64(frames) / speed)
+	out := make([]byte, outFrames*bytesPerFrame)
+	sample := func(frame, channel int) float64 {
+		frame = min(frame, frames-1)
+		return float64(int16(binary.LittleEndian.Uint16(pcm[frame*bytesPerFrame+channel*2:])))
+	}
+	for i := range outFrames {
+		pos := float64(i) * speed
+		frame := int(pos)
+		frac := pos - float64(frame)
+		for channel := range 2 {
+			v := sample(frame, channel)*(1-frac) + sample(frame+1, channel)*frac
+			binary.LittleEndian.PutUint16(out[i*bytesPerFrame+channel*2:], uint16(int16(v)))
+		}
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package sound
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/NautiluX/8bites/pkg/atomicfile"
+)
+
+// Settings are the volumes chosen by the player, from 0 to 1.
+type Settings struct {
+	Master float64
+	Music  float64
+	SFX    float64
+	Muted  bool
+}
+
+// DefaultSettings are used until the player changes them.
+func DefaultSettings() Settings {
+	return Settings{Master: 1, Music: 0.8, SFX: 1}
+}
+
+// LoadSettings reads the settings from the file at path, if it exists.
+func LoadSettings(path string) (Settings, error) {
+	s := DefaultSettings()
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return DefaultSettings(), err
+	}
+	// The file may have been edited by hand.
+	s.Master = min(1, max(0, s.Master))
+	s.Music = min(1, max(0, s.Music))
+	s.SFX = min(1, max(0, s.SFX))
+	return s, nil
+}
+
+// Save writes the settings to the file at path.
+func (s Settings) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,55 @@
+package sound
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSettings(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		file string
+		want Settings
+	}{
+		{"missing", "", DefaultSettings()},
+		{"saved", `{"Master": 0.5, "Music": 0.25, "SFX": 0, "Muted": true}`, Settings{Master: 0.5, Music: 0.25, SFX: 0, Muted: true}},
+		{"partial", `{"Music": 0.1}`, Settings{Master: 1, Music: 0.1, SFX: 1}},
+		{"out of range", `{"Master": 7, "Music": -0.5, "SFX": 1.5}`, Settings{Master: 1, Music: 0, SFX: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".json")
+			if tt.file != "" {
+				err := os.WriteFile(path, []byte(tt.file), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			s, err := LoadSettings(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s != tt.want {
+				t.Errorf("settings are %+v, want %+v", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettingsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "settings.json")
+	saved := Settings{Master: 0.3, Music: 0.6, SFX: 0.9, Muted: true}
+	err := saved.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded != saved {
+		t.Errorf("loaded %+v, saved %+v", loaded, saved)
+	}
+}
@@ -0,0 +1,278 @@
+// Package sound plays the music and sound effects of the game on separate
+// buses, each with its own volume.
+package sound
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/NautiluX/8bites/pkg/sfxr"
+	"github.com/NautiluX/8bites/pkg/tracker"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+)
+
+const (
+	SampleRate = 44100
+	volumeStep = 0.1
+)
+
+// Bus groups sounds that share a volume.
+type Bus int
+
+const (
+	BusMusic Bus = iota
+	BusSFX
+)
+
+// Formats of sound files.
+const (
+	FormatWAV = "wav"
+	// FormatDMF is a DefleMask module, see package tracker.
+	FormatDMF = "dmf"
+	// FormatSfxr holds the JSON parameters of a sound effect, see package sfxr.
+	FormatSfxr = "sfxr"
+)
+
+// Loader returns the file of the sound with the given name, and its format.
+type Loader func(name string) ([]byte, string, error)
+
+// Manager plays all sounds of the game. Sounds are decoded once and kept in a
+// pool, so they can be played any number of times.
+type Manager struct {
+	backend Backend
+	load    Loader
+	// settingsPath is the file the settings are saved to, they aren't saved if it is empty.
+	settingsPath string
+	settings     Settings
+
+	// pool holds the decoded sounds by name.
+	pool map[string]*clip
+	sfx  []voice
+	// music holds the playing soundtracks, the last one is the current one.
+	// The others are fading out.
+	music   []*track
+	duck    fader
+	stinger Player
+	// stingerFade silences the music while a stinger plays.
+	stingerFade fader
+}
+
+// Voice limits how a sound effect is played.
+type Voice struct {
+	// MaxVoices is how many times the sound plays at once. The oldest one is
+	// stopped when another one starts. Unlimited if 0.
+	MaxVoices int
+	// PitchVariation randomly raises or lowers the pitch by up to this
+	// fraction, e.g. 0.1 for 10%. It is limited to maxPitchVariation.
+	PitchVariation float64
+}
+
+// maxPitchVariation keeps the pitch positive, so varied sounds play at most ten
+// times as long.
+const maxPitchVariation = 0.9
+
+// voice is a playing sound effect.
+type voice struct {
+	name   string
+	player Player
+}
+
+// NewManager creates a manager that plays on the backend, loads its sounds
+// with load and keeps its settings in the file at settingsPath.
+func NewManager(backend Backend, load Loader, settingsPath string) (*Manager, error) {
+	settings, err := LoadSettings(settingsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audio settings: %w", err)
+	}
+	return &Manager{
+		backend:      backend,
+		load:         load,
+		settingsPath: settingsPath,
+		settings:     settings,
+		pool:         map[string]*clip{},
+		duck:         fader{gain: 1, target: 1},
+		stingerFade:  fader{gain: 1, target: 1},
+	}, nil
+}
+
+// Settings returns the current volumes.
+func (m *Manager) Settings() Settings {
+	return m.settings
+}
+
+// clip is a decoded sound.
+type clip struct {
+	pcm []byte
+	// module is set for DefleMask modules. They are only rendered to pcm,
+	// played once, when they are used as sound effects.
+	module *tracker.Module
+}
+
+// clip returns the decoded sound, decoding it on first use.
+func (m *Manager) clip(name string) (*clip, error) {
+	if c, ok := m.pool[name]; ok {
+		return c, nil
+	}
+	data, format, err := m.load(name)
+	if err != nil {
+		return nil, err
+	}
+	c := &clip{}
+	switch format {
+	case FormatWAV:
+		var stream io.Reader
+		stream, err = wav.DecodeWithSampleRate(SampleRate, bytes.NewReader(data))
+		if err == nil {
+			c.pcm, err = io.ReadAll(stream)
+		}
+	case FormatDMF:
+		c.module, err = tracker.Parse(bytes.NewReader(data))
+	case FormatSfxr:
+		var params sfxr.Params
+		params, err = sfxr.Parse(data)
+		if err == nil {
+			c.pcm = sfxr.Generate(params, SampleRate)
+		}
+	default:
+		err = fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	m.pool[name] = c
+	return c, nil
+}
+
+// decode returns the PCM data of the sound, decoding it on first use.
+func (m *Manager) decode(name string) ([]byte, error) {
+	c, err := m.clip(name)
+	if err != nil {
+		return nil, err
+	}
+	if c.pcm == nil && c.module != nil {
+		c.pcm, err = io.ReadAll(tracker.NewStream(c.module, SampleRate, false))
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", name, err)
+		}
+	}
+	return c.pcm, nil
+}
+
+// Forget drops the sound from the pool, so it is loaded again on next use.
+func (m *Manager) Forget(name string) {
+	delete(m.pool, name)
+}
+
+// PlaySfx plays the sound once on the SFX bus.
+func (m *Manager) PlaySfx(name string, v Voice) error {
+	pcm, err := m.decode(name)
+	if err != nil {
+		return err
+	}
+	if v.MaxVoices > 0 {
+		m.stealVoices(name, v.MaxVoices-1)
+	}
+	if variation := min(v.PitchVariation, maxPitchVariation); variation > 0 {
+		// The pitch doesn't affect the simulation, so it doesn't need its random numbers.
+		pcm = resample(pcm, 1+(rand.Float64()*2-1)*variation)
+	}
+	player := m.backend.NewPlayerFromBytes(pcm)
+	player.SetVolume(m.volume(BusSFX))
+	player.Play()
+	m.sfx = append(m.sfx, voice{name: name, player: player})
+	return nil
+}
+
+// stealVoices stops the oldest voices of the sound until at most keep are playing.
+func (m *Manager) stealVoices(name string, keep int) {
+	playing := 0
+	for _, v := range m.sfx {
+		if v.name == name && v.player.IsPlaying() {
+			playing++
+		}
+	}
+	for i := 0; i < len(m.sfx) && playing > keep; i++ {
+		if m.sfx[i].name != name || !m.sfx[i].player.IsPlaying() {
+			continue
+		}
+		m.sfx[i].player.Pause()
+		playing--
+	}
+}
+
+// Update advances the fades of the music by dt and releases the sounds that
+// finished playing. It has to be called regularly, e.g. once per tick. If the
+// backend failed, the manager switches to the NullBackend and returns the error,
+// once.
+func (m *Manager) Update(dt time.Duration) error {
+	if err := m.backend.Err(); err != nil {
+		m.backend = NullBackend{}
+		return err
+	}
+	m.updateMusic(dt)
+	m.sfx = slices.DeleteFunc(m.sfx, func(v voice) bool {
+		if v.player.IsPlaying() {
+			return false
+		}
+		v.player.Close()
+		return true
+	})
+	return nil
+}
+
+// ToggleMute mutes or unmutes all buses.
+func (m *Manager) ToggleMute() {
+	m.settings.Muted = !m.settings.Muted
+	m.apply()
+}
+
+// ChangeVolume raises the master volume by steps, or lowers it for negative steps.
+func (m *Manager) ChangeVolume(steps int) {
+	m.settings.Master = min(1, max(0, m.settings.Master+float64(steps)*volumeStep))
+	m.apply()
+}
+
+// SetVolume sets the volume of a bus, from 0 to 1.
+func (m *Manager) SetVolume(bus Bus, volume float64) {
+	volume = min(1, max(0, volume))
+	switch bus {
+	case BusMusic:
+		m.settings.Music = volume
+	case BusSFX:
+		m.settings.SFX = volume
+	}
+	m.apply()
+}
+
+func (m *Manager) volume(bus Bus) float64 {
+	if m.settings.Muted {
+		return 0
+	}
+	switch bus {
+	case BusMusic:
+		return m.settings.Master * m.settings.Music
+	case BusSFX:
+		return m.settings.Master * m.settings.SFX
+	}
+	return m.settings.Master
+}
+
+// apply updates the volume of all playing sounds and saves the settings.
+func (m *Manager) apply() {
+	m.applyMusicVolume()
+	for _, v := range m.sfx {
+		v.player.SetVolume(m.volume(BusSFX))
+	}
+	if m.settingsPath == "" {
+		return
+	}
+	err := m.settings.Save(m.settingsPath)
+	if err != nil {
+		log.Printf("failed to save audio settings: %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+// Package spatial indexes where entities are, so the entities near a point can
+// be found without testing all of them.
+package spatial
+
+import (
+	"image"
+	"iter"
+)
+
+// Grid is a spatial hash of entities. The plane is split into square cells,
+// and every entity is listed in the cell of its position, e.g. its center.
+// Only the entities in the cells a query overlaps are tested, so queries stay
+// fast with thousands of entities. Cells are created as they are needed, so
+// the plane has no bounds.
+//
+// Queries visit the entities in the same order every time, as long as they
+// are inserted, moved and removed in the same order, so simulations using
+// the grid stay deterministic.
+type Grid[T comparable] struct {
+	cellSize int
+	// cells holds the entities of every cell, by the key of the cell.
+	cells map[uint64][]entry[T]
+	// positions holds the position of every entity, to find its cell.
+	positions map[T]image.Point
+}
+
+type entry[T comparable] struct {
+	item T
+	pos  image.Point
+}
+
+// NewGrid creates a grid with cells of the given size. Cells about the size
+// of the queries work best.
+func NewGrid[T comparable](cellSize int) *Grid[T] {
+	return &Grid[T]{
+		cellSize:  cellSize,
+		cells:     map[uint64][]entry[T]{},
+		positions: map[T]image.Point{},
+	}
+}
+
+// Len returns the number of entities in the grid.
+func (g *Grid[T]) Len() int {
+	return len(g.positions)
+}
+
+// Insert adds the entity at the given position, or moves it there if it
+// already is in the grid.
+func (g *Grid[T]) Insert(item T, pos image.Point) {
+	if _, ok := g.positions[item]; ok {
+		g.Update(item, pos)
+		return
+	}
+	g.positions[item] = pos
+	cell := g.cell(pos)
+	g.cells[cell] = append(g.cells[cell], entry[T]{item: item, pos: pos})
+}
+
+// Update moves the entity to the given position. Entities that aren't in the
+// grid are ignored, so moves of copies of an entity don't add them.
+func (g *Grid[T]) Update(item T, pos image.Point) {
+	old, ok := g.positions[item]
+	if !ok || old == pos {
+		return
+	}
+	g.positions[item] = pos
+	from, to := g.cell(old), g.cell(pos)
+	if from == to {
+		entries := g.cells[from]
+		entries[find(entries, item)].pos = pos
+		return
+	}
+	g.unlink(from, item)
+	g.cells[to] = append(g.cells[to], entry[T]{item: item, pos: pos})
+}
+
+// Remove removes the entity from the grid.
+func (g *Grid[T]) Remove(item T) {
+	pos, ok := g.positions[item]
+	if !ok {
+		return
+	}
+	g.unlink(g.cell(pos), item)
+	delete(g.positions, item)
+}
+
+// Clear removes all entities.
+func (g *Grid[T]) Clear() {
+	clear(g.cells)
+	clear(g.positions)
+}
+
+// Query returns the entities positioned in r. The grid must not be changed
+// while iterating, but it can be queried again.
+func (g *Grid[T]) Query(r image.Rectangle) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if r.Empty() {
+			return
+		}
+		first, last := g.cellOf(r.Min), g.cellOf(r.Max.Sub(image.Pt(1, 1)))
+		for y := first.Y; y <= last.Y; y++ {
+			for x := first.X; x <= last.X; x++ {
+				for _, e := range g.cells[cellKey(x, y)] {
+					if !e.pos.In(r) {
+						continue
+					}
+					if !yield(e.item) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+func (g *Grid[T]) unlink(cell uint64, item T) {
+	entries := g.cells[cell]
+	i := find(entries, item)
+	// The order is kept, so queries stay deterministic. Empty cells are kept
+	// too, so entities moving back and forth don't allocate.
+	g.cells[cell] = append(entries[:i], entries[i+1:]...)
+}
+
+// cellOf returns the coordinates of the cell the position is in.
+func (g *Grid[T]) cellOf(pos image.Point) image.Point {
+	return image.Pt(floorDiv(pos.X, g.cellSize), floorDiv(pos.Y, g.cellSize))
+}
+
+// cell returns the key of the cell the position is in.
+func (g *Grid[T]) cell(pos image.Point) uint64 {
+	c := g.cellOf(pos)
+	return cellKey(c.X, c.Y)
+}
+
+// cellKey packs the coordinates of a cell into a single number, which is
+// faster to look up than a point.
+func cellKey(x, y int) uint64 {
+	return uint64(uint32(x))<<32 | uint64(uint32(y))
+}
+
+func find[T comparable](entries []entry[T], item T) int {
+	for i, e := range entries {
+		if e.item == item {
+			return i
+		}
+	}
+	return -1
+}
+
+// floorDiv divides a by b, rounding towards negative infinity, so positions
+// left of and above the origin aren't in the first cell.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
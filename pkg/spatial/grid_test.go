@@ -0,0 +1,169 @@
+package spatial
+
+import (
+	"fmt"
+	"image"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// bruteForce finds the entities positioned in r by testing all of them.
+func bruteForce(positions map[int]image.Point, r image.Rectangle) []int {
+	var found []int
+	for item, pos := range positions {
+		if pos.In(r) {
+			found = append(found, item)
+		}
+	}
+	slices.Sort(found)
+	return found
+}
+
+func query(g *Grid[int], r image.Rectangle) []int {
+	found := slices.Collect(g.Query(r))
+	slices.Sort(found)
+	return found
+}
+
+// randomRect returns a rectangle of up to 100 pixels around the origin, so
+// queries cross the cells left of and above it.
+func randomRect(rng *rand.Rand) image.Rectangle {
+	x, y := rng.IntN(400)-200, rng.IntN(400)-200
+	return image.Rect(x, y, x+rng.IntN(100), y+rng.IntN(100))
+}
+
+func randomPoint(rng *rand.Rand) image.Point {
+	return image.Pt(rng.IntN(400)-200, rng.IntN(400)-200)
+}
+
+func checkQueries(t *testing.T, rng *rand.Rand, g *Grid[int], positions map[int]image.Point) {
+	t.Helper()
+	if g.Len() != len(positions) {
+		t.Fatalf("grid has %d entities, want %d", g.Len(), len(positions))
+	}
+	for range 200 {
+		r := randomRect(rng)
+		got, want := query(g, r), bruteForce(positions, r)
+		if !slices.Equal(got, want) {
+			t.Fatalf("query %v found %v, want %v", r, got, want)
+		}
+	}
+}
+
+func TestQueryMatchesBruteForce(t *testing.T) {
+	for _, cellSize := range []int{1, 7, 32, 1000} {
+		t.Run(fmt.Sprintf("cells of %d", cellSize), func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(uint64(cellSize), 0))
+			g := NewGrid[int](cellSize)
+			positions := map[int]image.Point{}
+			for item := range 500 {
+				pos := randomPoint(rng)
+				g.Insert(item, pos)
+				positions[item] = pos
+			}
+			checkQueries(t, rng, g, positions)
+
+			// Moves are mostly small, so entities stay in their cell or move to a
+			// neighbouring one, some jump across the plane.
+			for round := range 20 {
+				for item, pos := range positions {
+					if rng.IntN(4) == 0 {
+						pos = randomPoint(rng)
+					} else {
+						pos = pos.Add(image.Pt(rng.IntN(9)-4, rng.IntN(9)-4))
+					}
+					if round%2 == 0 {
+						g.Update(item, pos)
+					} else {
+						g.Insert(item, pos)
+					}
+					positions[item] = pos
+				}
+				for item := range positions {
+					if rng.IntN(10) == 0 {
+						g.Remove(item)
+						delete(positions, item)
+					}
+				}
+				checkQueries(t, rng, g, positions)
+			}
+		})
+	}
+}
+
+func TestNegativeCoordinates(t *testing.T) {
+	g := NewGrid[string](32)
+	g.Insert("left of origin", image.Pt(-1, 0))
+	g.Insert("above origin", image.Pt(0, -1))
+	g.Insert("origin", image.Pt(0, 0))
+	g.Insert("far", image.Pt(-33, -33))
+
+	tests := []struct {
+		r    image.Rectangle
+		want []string
+	}{
+		{image.Rect(0, 0, 32, 32), []string{"origin"}},
+		{image.Rect(-1, 0, 0, 1), []string{"left of origin"}},
+		{image.Rect(-32, -32, 0, 0), nil},
+		{image.Rect(-33, -33, 1, 1), []string{"above origin", "far", "left of origin", "origin"}},
+	}
+	for _, tt := range tests {
+		got := slices.Sorted(g.Query(tt.r))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("query %v found %v, want %v", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateAndRemoveAcrossCells(t *testing.T) {
+	g := NewGrid[string](32)
+	g.Insert("slime", image.Pt(16, 16))
+	g.Update("slime", image.Pt(-16, 48))
+	if got := slices.Collect(g.Query(image.Rect(0, 0, 32, 32))); len(got) != 0 {
+		t.Errorf("slime is still found in its old cell: %v", got)
+	}
+	if got := slices.Collect(g.Query(image.Rect(-32, 32, 0, 64))); !slices.Equal(got, []string{"slime"}) {
+		t.Errorf("slime isn't found in its new cell: %v", got)
+	}
+
+	g.Update("copy", image.Pt(0, 0))
+	if g.Len() != 1 {
+		t.Errorf("updating an unknown entity added it")
+	}
+
+	g.Remove("slime")
+	if got := slices.Collect(g.Query(image.Rect(-1000, -1000, 1000, 1000))); len(got) != 0 || g.Len() != 0 {
+		t.Errorf("removed slime is still found: %v", got)
+	}
+}
+
+// BenchmarkQuery finds the entities near a player among entities on a single
+// screen, with the grid and by testing all of them.
+func BenchmarkQuery(b *testing.B) {
+	const width, height, size = 640, 480, 32
+	for _, n := range []int{10, 100, 1000, 5000} {
+		rng := rand.New(rand.NewPCG(uint64(n), 0))
+		g := NewGrid[int](size)
+		positions := make([]image.Point, n)
+		for item := range positions {
+			positions[item] = image.Pt(rng.IntN(width), rng.IntN(height))
+			g.Insert(item, positions[item])
+		}
+		player := image.Rect(width/2-size/2, height/2-size/2, width/2+size/2+1, height/2+size/2+1)
+
+		b.Run(fmt.Sprintf("grid/%d", n), func(b *testing.B) {
+			for b.Loop() {
+				for range g.Query(player) {
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("all/%d", n), func(b *testing.B) {
+			for b.Loop() {
+				for _, pos := range positions {
+					_ = pos.In(player)
+				}
+			}
+		})
+	}
+}
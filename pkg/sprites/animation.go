@@ -0,0 +1,133 @@
+package sprites
+
+import "time"
+
+// DefaultFrameDuration is used for frames without a duration of their own.
+const DefaultFrameDuration = 100 * time.Millisecond
+
+// LoopMode defines what happens when an animation reaches its last frame.
+type LoopMode int
+
+const (
+	// LoopModeLoop starts over with the first frame.
+	LoopModeLoop LoopMode = iota
+	// LoopModePingPong plays the frames backwards, then forwards again.
+	LoopModePingPong
+	// LoopModeOnce stops at the last frame.
+	LoopModeOnce
+)
+
+type Animation struct {
+	Name   string
+	Frames int
+	// FrameNames and Durations are set for animations imported from Aseprite.
+	// Frames without a duration are shown for DefaultFrameDuration.
+	FrameNames []string
+	Durations  []time.Duration
+	// Direction is the direction the frames are played in first.
+	Direction Direction
+	Mode      LoopMode
+	// Repeat stops a looping animation after the given number of cycles,
+	// 0 means forever.
+	Repeat int
+	// Hitboxes override the hitboxes of the sprite while the animation plays.
+	Hitboxes Hitboxes
+	// OnComplete is called with the animated sprite whenever a cycle of the
+	// animation is completed.
+	OnComplete func(s *CharacterSprite)
+}
+
+// FrameDuration returns how long the given frame is shown.
+func (a *Animation) FrameDuration(frame int) time.Duration {
+	if frame < len(a.Durations) && a.Durations[frame] > 0 {
+		return a.Durations[frame]
+	}
+	return DefaultFrameDuration
+}
+
+func (a *Animation) reversed() bool {
+	return a.Direction == DirectionReverse || a.Direction == DirectionPingPongReverse
+}
+
+// ResetAnimation restarts the current animation.
+func (s *CharacterSprite) ResetAnimation() {
+	anim := &s.Animations[s.CurrentAnimation]
+	s.CurrentFrame = 0
+	s.frameStep = 1
+	if anim.reversed() && anim.Frames > 0 {
+		s.CurrentFrame = anim.Frames - 1
+		s.frameStep = -1
+	}
+	s.frameTime = 0
+	s.loops = 0
+	s.finished = false
+}
+
+// AnimationFinished reports whether the current animation has stopped, which
+// only happens for LoopModeOnce or a limited number of repeats.
+func (s *CharacterSprite) AnimationFinished() bool {
+	return s.finished
+}
+
+// Animate advances the current animation by the given simulation time.
+func (s *CharacterSprite) Animate(dt time.Duration) {
+	anim := &s.Animations[s.CurrentAnimation]
+	if s.finished || anim.Frames == 0 {
+		return
+	}
+	if s.frameStep == 0 {
+		s.frameStep = 1
+		if anim.reversed() {
+			s.frameStep = -1
+		}
+	}
+	s.frameTime += dt
+	for s.frameTime >= anim.FrameDuration(s.CurrentFrame) {
+		s.frameTime -= anim.FrameDuration(s.CurrentFrame)
+		s.nextFrame(anim)
+		if s.finished {
+			s.frameTime = 0
+			return
+		}
+	}
+}
+
+func (s *CharacterSprite) nextFrame(anim *Animation) {
+	next := s.CurrentFrame + s.frameStep
+	if next >= 0 && next < anim.Frames {
+		s.CurrentFrame = next
+		return
+	}
+
+	// The end of the frames is reached.
+	if anim.Mode == LoopModePingPong {
+		s.frameStep = -s.frameStep
+		// A ping-pong cycle is only completed when the first frame is reached again.
+		backAtStart := (s.frameStep == 1) != anim.reversed()
+		if !backAtStart {
+			s.stepBack(anim)
+			return
+		}
+	}
+	s.loops++
+	switch {
+	case anim.Mode == LoopModeOnce || (anim.Repeat > 0 && s.loops >= anim.Repeat):
+		s.finished = true
+	case anim.Mode == LoopModePingPong:
+		s.stepBack(anim)
+	case s.frameStep > 0:
+		s.CurrentFrame = 0
+	default:
+		s.CurrentFrame = anim.Frames - 1
+	}
+	if anim.OnComplete != nil {
+		anim.OnComplete(s)
+	}
+}
+
+// stepBack moves away from the end of the frames after a ping-pong bounce.
+func (s *CharacterSprite) stepBack(anim *Animation) {
+	if anim.Frames > 1 {
+		s.CurrentFrame += s.frameStep
+	}
+}
@@ -0,0 +1,68 @@
+package sprites
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAnimate(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name      string
+		animation Animation
+		// frames are the frames shown after each step of 100ms.
+		frames      []int
+		completions int
+		finished    bool
+	}{
+		{"forward", Animation{Frames: 3}, []int{1, 2, 0, 1, 2, 0}, 2, false},
+		{"reverse", Animation{Frames: 3, Direction: DirectionReverse}, []int{1, 0, 2, 1, 0}, 1, false},
+		{"ping-pong", Animation{Frames: 3, Mode: LoopModePingPong}, []int{1, 2, 1, 0, 1, 2, 1, 0}, 1, false},
+		{"ping-pong reverse", Animation{Frames: 3, Direction: DirectionPingPongReverse, Mode: LoopModePingPong}, []int{1, 0, 1, 2, 1, 0, 1, 2}, 1, false},
+		{"ping-pong single frame", Animation{Frames: 1, Mode: LoopModePingPong}, []int{0, 0, 0, 0}, 2, false},
+		{"once", Animation{Frames: 3, Mode: LoopModeOnce}, []int{1, 2, 2, 2}, 1, true},
+		{"once reverse", Animation{Frames: 3, Direction: DirectionReverse, Mode: LoopModeOnce}, []int{1, 0, 0}, 1, true},
+		{"repeat", Animation{Frames: 2, Repeat: 2}, []int{1, 0, 1, 1, 1}, 2, true},
+		{"durations", Animation{Frames: 2, Durations: []time.Duration{100 * ms, 200 * ms}}, []int{1, 1, 0, 1, 1, 0}, 2, false},
+		{"empty", Animation{}, []int{0, 0}, 0, false},
+		{"empty reverse", Animation{Direction: DirectionReverse}, []int{0, 0}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completions := 0
+			tt.animation.OnComplete = func(*CharacterSprite) { completions++ }
+			s := &CharacterSprite{Animations: []Animation{tt.animation}}
+			s.ResetAnimation()
+			var frames []int
+			for range tt.frames {
+				s.Animate(100 * ms)
+				frames = append(frames, s.CurrentFrame)
+			}
+			if !slices.Equal(frames, tt.frames) {
+				t.Errorf("frames are %v, want %v", frames, tt.frames)
+			}
+			if completions != tt.completions {
+				t.Errorf("%d cycles were completed, want %d", completions, tt.completions)
+			}
+			if s.AnimationFinished() != tt.finished {
+				t.Errorf("finished is %v, want %v", s.AnimationFinished(), tt.finished)
+			}
+		})
+	}
+}
+
+func TestResetAnimation(t *testing.T) {
+	s := &CharacterSprite{Animations: []Animation{{Frames: 3, Direction: DirectionReverse, Mode: LoopModeOnce}}}
+	s.ResetAnimation()
+	for range 5 {
+		s.Animate(DefaultFrameDuration)
+	}
+	if !s.AnimationFinished() {
+		t.Fatal("animation didn't finish")
+	}
+	s.ResetAnimation()
+	if s.CurrentFrame != 2 || s.AnimationFinished() {
+		t.Errorf("reset animation is at frame %d, finished %v, want frame 2 and not finished", s.CurrentFrame, s.AnimationFinished())
+	}
+}
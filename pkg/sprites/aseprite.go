@@ -0,0 +1,341 @@
+package sprites
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"time"
+)
+
+// Direction is the order in which the frames of an animation are played.
+// The values match the loop directions stored in .aseprite files.
+type Direction int
+
+const (
+	DirectionForward Direction = iota
+	DirectionReverse
+	DirectionPingPong
+	DirectionPingPongReverse
+)
+
+const (
+	asepriteMagic      = 0xA5E0
+	asepriteFrameMagic = 0xF1FA
+	asepriteChunkTags  = 0x2018
+	asepriteChunkSlice = 0x2022
+
+	asepriteSliceNinePatch = 1
+	asepriteSlicePivot     = 2
+)
+
+// AsepriteFile holds the metadata of an .aseprite file needed to animate the
+// sprite sheet exported from it.
+type AsepriteFile struct {
+	Width     int
+	Height    int
+	Durations []time.Duration
+	Tags      []AsepriteTag
+	// Slices define the hitboxes of the animations, see Animations.
+	Slices []AsepriteSlice
+}
+
+// AsepriteTag is a named range of frames, which becomes an animation.
+type AsepriteTag struct {
+	Name      string
+	From      int
+	To        int
+	Direction Direction
+	// Repeat is how often the animation is played, 0 means forever.
+	Repeat int
+}
+
+// AsepriteSlice is a named area of the sprite, which can change from frame
+// to frame.
+type AsepriteSlice struct {
+	Name string
+	Keys []AsepriteSliceKey
+}
+
+// AsepriteSliceKey sets the bounds of a slice from a frame on. The slice is
+// hidden from the frame on if the bounds are empty.
+type AsepriteSliceKey struct {
+	Frame  int
+	Bounds image.Rectangle
+}
+
+// BoundsAt returns the bounds of the slice in the frame, which are empty if
+// the slice is hidden.
+func (s AsepriteSlice) BoundsAt(frame int) image.Rectangle {
+	var bounds image.Rectangle
+	for _, key := range s.Keys {
+		if key.Frame > frame {
+			break
+		}
+		bounds = key.Bounds
+	}
+	return bounds
+}
+
+type asepriteHeader struct {
+	FileSize   uint32
+	Magic      uint16
+	Frames     uint16
+	Width      uint16
+	Height     uint16
+	ColorDepth uint16
+	Flags      uint32
+	Speed      uint16
+	_          [8]byte
+	_          [4]byte
+	NumColors  uint16
+	_          [10]byte
+	_          [84]byte
+}
+
+type asepriteFrameHeader struct {
+	Size      uint32
+	Magic     uint16
+	OldChunks uint16
+	Duration  uint16
+	_         [2]byte
+	NewChunks uint32
+}
+
+type asepriteChunkHeader struct {
+	Size uint32
+	Type uint16
+}
+
+type asepriteSliceHeader struct {
+	Keys  uint32
+	Flags uint32
+	_     [4]byte
+}
+
+type asepriteSliceKeyHeader struct {
+	Frame  uint32
+	X      int32
+	Y      int32
+	Width  uint32
+	Height uint32
+}
+
+type asepriteTagHeader struct {
+	From      uint16
+	To        uint16
+	Direction uint8
+	Repeat    uint16
+	_         [6]byte
+	_         [4]byte
+}
+
+// ParseAseprite reads the frame durations, tags and slices of an .aseprite file.
+// Pixel data is skipped, the images are loaded from the exported PNG.
+func ParseAseprite(r io.Reader) (*AsepriteFile, error) {
+	var header asepriteHeader
+	err := binary.Read(r, binary.LittleEndian, &header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aseprite header: %w", err)
+	}
+	if header.Magic != asepriteMagic {
+		return nil, fmt.Errorf("not an aseprite file")
+	}
+
+	f := &AsepriteFile{
+		Width:  int(header.Width),
+		Height: int(header.Height),
+	}
+	// The sizes of frames and chunks are checked against the sizes of what
+	// contains them, so a broken file fails instead of panicking.
+	fileRemaining := int64(header.FileSize) - int64(binary.Size(header))
+	for i := 0; i < int(header.Frames); i++ {
+		var frame asepriteFrameHeader
+		err := binary.Read(r, binary.LittleEndian, &frame)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read header of frame %d: %w", i, err)
+		}
+		if frame.Magic != asepriteFrameMagic {
+			return nil, fmt.Errorf("invalid header of frame %d", i)
+		}
+		remaining := int64(frame.Size) - int64(binary.Size(frame))
+		if remaining < 0 || int64(frame.Size) > fileRemaining {
+			return nil, fmt.Errorf("invalid size of frame %d", i)
+		}
+		fileRemaining -= int64(frame.Size)
+		f.Durations = append(f.Durations, time.Duration(frame.Duration)*time.Millisecond)
+
+		chunks := int(frame.NewChunks)
+		if chunks == 0 {
+			chunks = int(frame.OldChunks)
+		}
+		for range chunks {
+			var chunk asepriteChunkHeader
+			err := binary.Read(r, binary.LittleEndian, &chunk)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read chunk in frame %d: %w", i, err)
+			}
+			size := int64(chunk.Size) - int64(binary.Size(chunk))
+			if size < 0 || int64(chunk.Size) > remaining {
+				return nil, fmt.Errorf("invalid size of chunk in frame %d", i)
+			}
+			remaining -= int64(chunk.Size)
+			// The data is read as it arrives, so a file that is shorter than
+			// it claims doesn't allocate memory for data that isn't there.
+			data, err := io.ReadAll(io.LimitReader(r, size))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read chunk in frame %d: %w", i, err)
+			}
+			if int64(len(data)) < size {
+				return nil, fmt.Errorf("failed to read chunk in frame %d: %w", i, io.ErrUnexpectedEOF)
+			}
+			switch chunk.Type {
+			case asepriteChunkTags:
+				f.Tags, err = parseAsepriteTags(data)
+				if err != nil {
+					return nil, err
+				}
+			case asepriteChunkSlice:
+				slice, err := parseAsepriteSlice(data)
+				if err != nil {
+					return nil, err
+				}
+				f.Slices = append(f.Slices, slice)
+			}
+		}
+	}
+	for _, tag := range f.Tags {
+		if tag.From > tag.To || tag.To >= len(f.Durations) {
+			return nil, fmt.Errorf("tag %s has frames %d to %d, but the file has %d frames", tag.Name, tag.From, tag.To, len(f.Durations))
+		}
+	}
+	return f, nil
+}
+
+func parseAsepriteTags(data []byte) ([]AsepriteTag, error) {
+	r := bytes.NewReader(data)
+	var count uint16
+	err := binary.Read(r, binary.LittleEndian, &count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+	_, err = r.Seek(8, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+	tags := make([]AsepriteTag, 0, count)
+	for range count {
+		var header asepriteTagHeader
+		err := binary.Read(r, binary.LittleEndian, &header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tag: %w", err)
+		}
+		name, err := readAsepriteString(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tag name: %w", err)
+		}
+		tags = append(tags, AsepriteTag{
+			Name:      name,
+			From:      int(header.From),
+			To:        int(header.To),
+			Direction: Direction(header.Direction),
+			Repeat:    int(header.Repeat),
+		})
+	}
+	return tags, nil
+}
+
+func parseAsepriteSlice(data []byte) (AsepriteSlice, error) {
+	r := bytes.NewReader(data)
+	var header asepriteSliceHeader
+	err := binary.Read(r, binary.LittleEndian, &header)
+	if err != nil {
+		return AsepriteSlice{}, fmt.Errorf("failed to read slice: %w", err)
+	}
+	name, err := readAsepriteString(r)
+	if err != nil {
+		return AsepriteSlice{}, fmt.Errorf("failed to read slice name: %w", err)
+	}
+	slice := AsepriteSlice{Name: name}
+	for range header.Keys {
+		var key asepriteSliceKeyHeader
+		err := binary.Read(r, binary.LittleEndian, &key)
+		if err != nil {
+			return AsepriteSlice{}, fmt.Errorf("failed to read key of slice %s: %w", name, err)
+		}
+		// The center of nine-patches and the pivot aren't used.
+		skip := 0
+		if header.Flags&asepriteSliceNinePatch != 0 {
+			skip += 16
+		}
+		if header.Flags&asepriteSlicePivot != 0 {
+			skip += 8
+		}
+		_, err = r.Seek(int64(skip), io.SeekCurrent)
+		if err != nil {
+			return AsepriteSlice{}, fmt.Errorf("failed to read key of slice %s: %w", name, err)
+		}
+		x, y := int(key.X), int(key.Y)
+		slice.Keys = append(slice.Keys, AsepriteSliceKey{
+			Frame:  int(key.Frame),
+			Bounds: image.Rect(x, y, x+int(key.Width), y+int(key.Height)),
+		})
+	}
+	return slice, nil
+}
+
+func readAsepriteString(r io.Reader) (string, error) {
+	var length uint16
+	err := binary.Read(r, binary.LittleEndian, &length)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, length)
+	_, err = io.ReadFull(r, buf)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// Animations returns one animation per tag, or a single "idle" animation with
+// all frames if the file has no tags. The sprite sheet is expected to have one
+// row per animation, which is how the sheets of this game are exported.
+// Frames are named like in Aseprite's JSON export, e.g. "yellow 12.aseprite".
+// Slices named "hitbox", "hurtbox" or "pickup" become the hitboxes of the
+// animations, with the bounds they have in the first frame of the animation.
+// They are boxes, or circles if the name ends in ":circle".
+func (f *AsepriteFile) Animations(title string) []Animation {
+	tags := f.Tags
+	if len(tags) == 0 {
+		tags = []AsepriteTag{{Name: "idle", From: 0, To: len(f.Durations) - 1}}
+	}
+	animations := make([]Animation, 0, len(tags))
+	for _, tag := range tags {
+		animation := Animation{
+			Name:      tag.Name,
+			Frames:    tag.To - tag.From + 1,
+			Direction: tag.Direction,
+			Repeat:    tag.Repeat,
+		}
+		switch {
+		case tag.Direction == DirectionPingPong || tag.Direction == DirectionPingPongReverse:
+			animation.Mode = LoopModePingPong
+		case tag.Repeat == 1:
+			animation.Mode = LoopModeOnce
+		}
+		for _, slice := range f.Slices {
+			bounds := slice.BoundsAt(tag.From)
+			if !bounds.Empty() {
+				sliceShape(&animation.Hitboxes, slice.Name, bounds)
+			}
+		}
+		for i := tag.From; i <= tag.To && i < len(f.Durations); i++ {
+			animation.FrameNames = append(animation.FrameNames, fmt.Sprintf("%s %d.aseprite", title, i))
+			animation.Durations = append(animation.Durations, f.Durations[i])
+		}
+		animations = append(animations, animation)
+	}
+	return animations
+}
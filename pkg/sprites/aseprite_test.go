@@ -0,0 +1,180 @@
+package sprites
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testChunk is a chunk of an .aseprite file, with its size as stored in the
+// file, or the actual size if it is 0.
+type testChunk struct {
+	Type uint16
+	Data []byte
+	Size uint32
+}
+
+// testFrame is a frame of an .aseprite file, with its size as stored in the
+// file, or the actual size if it is 0.
+type testFrame struct {
+	Duration uint16
+	Chunks   []testChunk
+	Size     uint32
+}
+
+// encodeAseprite writes an .aseprite file without any pixel data.
+func encodeAseprite(t *testing.T, frames ...testFrame) []byte {
+	t.Helper()
+	write := func(buf *bytes.Buffer, v any) {
+		if err := binary.Write(buf, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var body bytes.Buffer
+	for _, frame := range frames {
+		var chunks bytes.Buffer
+		for _, chunk := range frame.Chunks {
+			size := chunk.Size
+			if size == 0 {
+				size = uint32(binary.Size(asepriteChunkHeader{}) + len(chunk.Data))
+			}
+			write(&chunks, asepriteChunkHeader{Size: size, Type: chunk.Type})
+			chunks.Write(chunk.Data)
+		}
+		size := frame.Size
+		if size == 0 {
+			size = uint32(binary.Size(asepriteFrameHeader{}) + chunks.Len())
+		}
+		write(&body, asepriteFrameHeader{
+			Size:      size,
+			Magic:     asepriteFrameMagic,
+			Duration:  frame.Duration,
+			NewChunks: uint32(len(frame.Chunks)),
+		})
+		body.Write(chunks.Bytes())
+	}
+	var file bytes.Buffer
+	write(&file, asepriteHeader{
+		FileSize:   uint32(binary.Size(asepriteHeader{}) + body.Len()),
+		Magic:      asepriteMagic,
+		Frames:     uint16(len(frames)),
+		Width:      32,
+		Height:     24,
+		ColorDepth: 32,
+	})
+	file.Write(body.Bytes())
+	return file.Bytes()
+}
+
+// tagsChunk encodes the tags like Aseprite stores them.
+func tagsChunk(t *testing.T, tags ...AsepriteTag) testChunk {
+	t.Helper()
+	var buf bytes.Buffer
+	write := func(v any) {
+		if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(uint16(len(tags)))
+	write([8]byte{})
+	for _, tag := range tags {
+		write(asepriteTagHeader{
+			From:      uint16(tag.From),
+			To:        uint16(tag.To),
+			Direction: uint8(tag.Direction),
+			Repeat:    uint16(tag.Repeat),
+		})
+		write(uint16(len(tag.Name)))
+		buf.WriteString(tag.Name)
+	}
+	return testChunk{Type: asepriteChunkTags, Data: buf.Bytes()}
+}
+
+func TestParseAseprite(t *testing.T) {
+	tags := []AsepriteTag{
+		{Name: "walk", From: 0, To: 1},
+		{Name: "bounce", From: 1, To: 2, Direction: DirectionPingPong, Repeat: 3},
+	}
+	data := encodeAseprite(t,
+		testFrame{Duration: 100, Chunks: []testChunk{
+			tagsChunk(t, tags...),
+			// Chunks the game doesn't use are skipped.
+			{Type: 0x2019, Data: make([]byte, 40)},
+		}},
+		testFrame{Duration: 150},
+		testFrame{Duration: 80, Chunks: []testChunk{{Type: 0x2005, Data: make([]byte, 100)}}},
+	)
+	f, err := ParseAseprite(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Width != 32 || f.Height != 24 {
+		t.Errorf("size is %dx%d, want 32x24", f.Width, f.Height)
+	}
+	durations := []time.Duration{100 * time.Millisecond, 150 * time.Millisecond, 80 * time.Millisecond}
+	if !slices.Equal(f.Durations, durations) {
+		t.Errorf("durations are %v, want %v", f.Durations, durations)
+	}
+	if !slices.Equal(f.Tags, tags) {
+		t.Errorf("tags are %+v, want %+v", f.Tags, tags)
+	}
+}
+
+func TestParseAsepriteMalformed(t *testing.T) {
+	valid := encodeAseprite(t, testFrame{Duration: 100, Chunks: []testChunk{{Type: 0x2005, Data: make([]byte, 16)}}})
+	headerSize := binary.Size(asepriteHeader{})
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated header", valid[:headerSize-1]},
+		{"not an aseprite file", append([]byte("\x00\x00\x00\x00PNG"), valid[7:]...)},
+		{"truncated frame", valid[:len(valid)-8]},
+		{"chunk smaller than its header", encodeAseprite(t, testFrame{Chunks: []testChunk{{Type: 0x2005, Size: 2}}})},
+		{"chunk of size 0xFFFFFFFF", encodeAseprite(t, testFrame{Chunks: []testChunk{{Type: 0x2005, Size: 0xFFFFFFFF}}})},
+		{"chunk larger than its frame", encodeAseprite(t, testFrame{Size: 40, Chunks: []testChunk{{Type: 0x2005, Data: make([]byte, 100)}}})},
+		{"frame smaller than its header", encodeAseprite(t, testFrame{Size: 4})},
+		{"frame larger than the file", encodeAseprite(t, testFrame{Size: 1 << 30, Chunks: []testChunk{{Type: 0x2005, Size: 1 << 29}}})},
+		{"truncated tags", encodeAseprite(t, testFrame{Chunks: []testChunk{{Type: asepriteChunkTags, Data: []byte{1, 0, 0}}}})},
+		{"tag beyond the last frame", encodeAseprite(t, testFrame{Chunks: []testChunk{tagsChunk(t, AsepriteTag{Name: "walk", From: 0, To: 1})}})},
+		{"tag ending before it starts", encodeAseprite(t, testFrame{}, testFrame{Chunks: []testChunk{tagsChunk(t, AsepriteTag{Name: "walk", From: 1, To: 0})}})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAseprite(bytes.NewReader(tt.data))
+			if err == nil {
+				t.Error("malformed file was parsed")
+			}
+		})
+	}
+}
+
+func TestParseShippedSprites(t *testing.T) {
+	paths, err := filepath.Glob("../../assets/sprites/*/*.aseprite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no sprites found")
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := ParseAseprite(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("failed to parse %s: %v", path, err)
+			continue
+		}
+		if len(f.Durations) == 0 {
+			t.Errorf("%s has no frames", strings.TrimPrefix(path, "../../"))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package sprites
+
+import (
+	"image"
+	"strings"
+)
+
+// Shape is an area of a sprite that collides, relative to the top left corner
+// of the sprite. Circle and Box are supported, other shapes collide with
+// their bounds.
+type Shape interface {
+	// Bounds returns the smallest rectangle containing the shape.
+	Bounds() image.Rectangle
+}
+
+// Circle is a round shape.
+type Circle struct {
+	Center image.Point
+	Radius int
+}
+
+func (c Circle) Bounds() image.Rectangle {
+	return image.Rect(c.Center.X-c.Radius, c.Center.Y-c.Radius, c.Center.X+c.Radius+1, c.Center.Y+c.Radius+1)
+}
+
+// Box is an axis-aligned rectangle.
+type Box struct {
+	Rect image.Rectangle
+}
+
+func (b Box) Bounds() image.Rectangle {
+	return b.Rect
+}
+
+// Hitboxes are the shapes a sprite collides with. Shapes that aren't set
+// fall back to the hitbox.
+type Hitboxes struct {
+	// Hitbox touches other sprites. Enemies hurt with it, items are picked up by it.
+	Hitbox Shape
+	// Hurtbox is where the sprite is hurt by the hitboxes of enemies. A
+	// hurtbox smaller than the sprite forgives near misses.
+	Hurtbox Shape
+	// Pickup is where the sprite picks up items. A pickup larger than the
+	// sprite makes items easier to collect.
+	Pickup Shape
+}
+
+// Overlaps reports whether shape a, of a sprite at pa, overlaps shape b, of a
+// sprite at pb. Touching edges don't overlap.
+func Overlaps(a Shape, pa image.Point, b Shape, pb image.Point) bool {
+	switch a := a.(type) {
+	case Circle:
+		a.Center = a.Center.Add(pa)
+		switch b := b.(type) {
+		case Circle:
+			b.Center = b.Center.Add(pb)
+			d := a.Center.Sub(b.Center)
+			r := a.Radius + b.Radius
+			return d.X*d.X+d.Y*d.Y < r*r
+		case Box:
+			return circleOverlapsRect(a, b.Rect.Add(pb))
+		}
+	case Box:
+		if b, ok := b.(Circle); ok {
+			b.Center = b.Center.Add(pb)
+			return circleOverlapsRect(b, a.Rect.Add(pa))
+		}
+	}
+	return a.Bounds().Add(pa).Overlaps(b.Bounds().Add(pb))
+}
+
+func circleOverlapsRect(c Circle, r image.Rectangle) bool {
+	if r.Empty() {
+		return false
+	}
+	// The closest point of the rectangle to the center decides.
+	closest := image.Pt(min(max(c.Center.X, r.Min.X), r.Max.X), min(max(c.Center.Y, r.Min.Y), r.Max.Y))
+	d := c.Center.Sub(closest)
+	return d.X*d.X+d.Y*d.Y < c.Radius*c.Radius
+}
+
+// reach returns how far the shape extends from the point, in either axis.
+func reach(s Shape, from image.Point) int {
+	if s == nil {
+		return 0
+	}
+	b := s.Bounds()
+	return max(from.X-b.Min.X, b.Max.X-from.X, from.Y-b.Min.Y, b.Max.Y-from.Y)
+}
+
+// sliceShape turns a slice of an Aseprite file into a hitbox. Slices named
+// "hitbox", "hurtbox" or "pickup" are boxes, with the suffix ":circle", e.g.
+// "hurtbox:circle", they are the largest circle in their bounds.
+func sliceShape(hitboxes *Hitboxes, name string, bounds image.Rectangle) {
+	role, kind, _ := strings.Cut(strings.ToLower(name), ":")
+	var shape Shape = Box{Rect: bounds}
+	if kind == "circle" {
+		c := bounds.Min.Add(bounds.Max).Div(2)
+		shape = Circle{Center: c, Radius: min(bounds.Dx(), bounds.Dy()) / 2}
+	}
+	switch role {
+	case "hitbox":
+		hitboxes.Hitbox = shape
+	case "hurtbox":
+		hitboxes.Hurtbox = shape
+	case "pickup":
+		hitboxes.Pickup = shape
+	}
+}
@@ -0,0 +1,168 @@
+package sprites
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"testing"
+)
+
+func TestOverlaps(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Shape
+		pa   image.Point
+		b    Shape
+		pb   image.Point
+		want bool
+	}{
+		{"circles overlapping", Circle{Radius: 5}, image.Pt(0, 0), Circle{Radius: 5}, image.Pt(9, 0), true},
+		{"circles touching", Circle{Radius: 5}, image.Pt(0, 0), Circle{Radius: 5}, image.Pt(10, 0), false},
+		{"circles apart", Circle{Radius: 5}, image.Pt(0, 0), Circle{Radius: 5}, image.Pt(20, 0), false},
+		// The bounds overlap, the circles don't.
+		{"circles diagonal near miss", Circle{Radius: 5}, image.Pt(0, 0), Circle{Radius: 5}, image.Pt(8, 8), false},
+		{"circles diagonal", Circle{Radius: 5}, image.Pt(0, 0), Circle{Radius: 5}, image.Pt(7, 7), true},
+		{"circle centers relative to the sprite", Circle{Center: image.Pt(8, 8), Radius: 2}, image.Pt(0, 0), Circle{Center: image.Pt(8, 8), Radius: 2}, image.Pt(3, 0), true},
+
+		{"circle in box", Circle{Center: image.Pt(5, 5), Radius: 2}, image.Pt(0, 0), Box{Rect: image.Rect(0, 0, 10, 10)}, image.Pt(0, 0), true},
+		{"circle overlapping box side", Circle{Radius: 5}, image.Pt(0, 0), Box{Rect: image.Rect(4, -2, 10, 2)}, image.Pt(0, 0), true},
+		{"circle touching box side", Circle{Radius: 5}, image.Pt(0, 0), Box{Rect: image.Rect(5, -2, 10, 2)}, image.Pt(0, 0), false},
+		// The circle reaches into the bounds of the box, but not its corner.
+		{"circle near miss of box corner", Circle{Radius: 5}, image.Pt(0, 0), Box{Rect: image.Rect(4, 4, 10, 10)}, image.Pt(0, 0), false},
+		{"circle overlapping box corner", Circle{Radius: 5}, image.Pt(0, 0), Box{Rect: image.Rect(3, 3, 10, 10)}, image.Pt(0, 0), true},
+		{"box overlapping circle", Box{Rect: image.Rect(3, 3, 10, 10)}, image.Pt(0, 0), Circle{Radius: 5}, image.Pt(0, 0), true},
+		{"box near miss of circle", Box{Rect: image.Rect(4, 4, 10, 10)}, image.Pt(0, 0), Circle{Radius: 5}, image.Pt(0, 0), false},
+		{"box moved onto circle", Box{Rect: image.Rect(0, 0, 4, 4)}, image.Pt(-2, -2), Circle{Radius: 1}, image.Pt(0, 0), true},
+		{"circle and empty box", Circle{Radius: 5}, image.Pt(0, 0), Box{}, image.Pt(0, 0), false},
+
+		{"boxes overlapping", Box{Rect: image.Rect(0, 0, 10, 10)}, image.Pt(0, 0), Box{Rect: image.Rect(0, 0, 10, 10)}, image.Pt(9, 9), true},
+		{"boxes touching", Box{Rect: image.Rect(0, 0, 10, 10)}, image.Pt(0, 0), Box{Rect: image.Rect(0, 0, 10, 10)}, image.Pt(10, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Overlaps(tt.a, tt.pa, tt.b, tt.pb); got != tt.want {
+				t.Errorf("Overlaps(%v, %v, %v, %v) = %v, want %v", tt.a, tt.pa, tt.b, tt.pb, got, tt.want)
+			}
+			if got := Overlaps(tt.b, tt.pb, tt.a, tt.pa); got != tt.want {
+				t.Errorf("Overlaps(%v, %v, %v, %v) = %v, want %v", tt.b, tt.pb, tt.a, tt.pa, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSliceShape(t *testing.T) {
+	bounds := image.Rect(2, 4, 12, 10)
+	circle := Circle{Center: image.Pt(7, 7), Radius: 3}
+	tests := []struct {
+		name string
+		want Hitboxes
+	}{
+		{"hitbox", Hitboxes{Hitbox: Box{Rect: bounds}}},
+		{"Hurtbox", Hitboxes{Hurtbox: Box{Rect: bounds}}},
+		{"pickup:circle", Hitboxes{Pickup: circle}},
+		{"HITBOX:Circle", Hitboxes{Hitbox: circle}},
+		{"hurtbox:star", Hitboxes{Hurtbox: Box{Rect: bounds}}},
+		{"weapon", Hitboxes{}},
+	}
+	for _, tt := range tests {
+		var got Hitboxes
+		sliceShape(&got, tt.name, bounds)
+		if got != tt.want {
+			t.Errorf("slice %q is %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// sliceChunk encodes a slice chunk like Aseprite stores it.
+func sliceChunk(t *testing.T, name string, flags uint32, keys ...asepriteSliceKeyHeader) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	write := func(v any) {
+		if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(asepriteSliceHeader{Keys: uint32(len(keys)), Flags: flags})
+	write(uint16(len(name)))
+	buf.WriteString(name)
+	for _, key := range keys {
+		write(key)
+		if flags&asepriteSliceNinePatch != 0 {
+			write([16]byte{})
+		}
+		if flags&asepriteSlicePivot != 0 {
+			write([8]byte{})
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestParseAsepriteSlice(t *testing.T) {
+	keys := []asepriteSliceKeyHeader{
+		{Frame: 0, X: 2, Y: 3, Width: 4, Height: 5},
+		{Frame: 2, X: -1, Y: 0, Width: 8, Height: 8},
+		{Frame: 4},
+	}
+	want := []AsepriteSliceKey{
+		{Frame: 0, Bounds: image.Rect(2, 3, 6, 8)},
+		{Frame: 2, Bounds: image.Rect(-1, 0, 7, 8)},
+		{Frame: 4, Bounds: image.Rect(0, 0, 0, 0)},
+	}
+	tests := []struct {
+		name  string
+		flags uint32
+	}{
+		{"plain", 0},
+		{"nine-patch", asepriteSliceNinePatch},
+		{"pivot", asepriteSlicePivot},
+		{"nine-patch and pivot", asepriteSliceNinePatch | asepriteSlicePivot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slice, err := parseAsepriteSlice(sliceChunk(t, "hurtbox:circle", tt.flags, keys...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if slice.Name != "hurtbox:circle" {
+				t.Errorf("name is %q, want %q", slice.Name, "hurtbox:circle")
+			}
+			if len(slice.Keys) != len(want) {
+				t.Fatalf("slice has %d keys, want %d", len(slice.Keys), len(want))
+			}
+			for i := range want {
+				if slice.Keys[i] != want[i] {
+					t.Errorf("key %d is %+v, want %+v", i, slice.Keys[i], want[i])
+				}
+			}
+		})
+	}
+
+	truncated := sliceChunk(t, "hitbox", asepriteSlicePivot, keys...)
+	_, err := parseAsepriteSlice(truncated[:len(truncated)-10])
+	if err == nil {
+		t.Error("truncated slice was parsed")
+	}
+}
+
+func TestSliceBoundsAt(t *testing.T) {
+	slice := AsepriteSlice{Keys: []AsepriteSliceKey{
+		{Frame: 1, Bounds: image.Rect(0, 0, 4, 4)},
+		{Frame: 3, Bounds: image.Rect(2, 2, 6, 6)},
+		{Frame: 5},
+	}}
+	tests := []struct {
+		frame int
+		want  image.Rectangle
+	}{
+		{0, image.Rectangle{}},
+		{1, image.Rect(0, 0, 4, 4)},
+		{2, image.Rect(0, 0, 4, 4)},
+		{3, image.Rect(2, 2, 6, 6)},
+		{5, image.Rectangle{}},
+	}
+	for _, tt := range tests {
+		if got := slice.BoundsAt(tt.frame); got != tt.want {
+			t.Errorf("bounds at frame %d are %v, want %v", tt.frame, got, tt.want)
+		}
+	}
+}
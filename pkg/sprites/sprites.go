@@ -2,8 +2,10 @@ package sprites
 
 import (
 	"image"
-	"math"
+	"strings"
+	"time"
 
+	"github.com/NautiluX/8bites/pkg/spatial"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
@@ -35,6 +37,18 @@ type CharacterSprite struct {
 	CurrentVx        int
 	CurrentVy        int
 	Id               SpriteId
+	// Hitboxes are the shapes the sprite collides with, unless the current
+	// animation has its own. Without a hitbox the sprite collides with a
+	// circle a quarter of its width around its center.
+	Hitboxes Hitboxes
+
+	// index is kept up to date when the sprite moves, see Track.
+	index *Index
+
+	frameTime time.Duration
+	frameStep int
+	loops     int
+	finished  bool
 }
 
 type Player struct {
@@ -46,19 +60,127 @@ type Player struct {
 	LastPoints    int
 }
 
+// Index is a spatial index of sprites.
+type Index = spatial.Grid[*CharacterSprite]
+
+// NewIndex creates an index with cells of the size of a tile.
+func NewIndex() *Index {
+	return spatial.NewGrid[*CharacterSprite](32)
+}
+
+// Track adds the sprite to the index at its center, which is then updated
+// whenever the sprite moves. Copies of the sprite don't update the index.
+func (s *CharacterSprite) Track(index *Index) {
+	s.index = index
+	index.Insert(s, s.Center())
+}
+
+// Untrack removes the sprite from its index.
+func (s *CharacterSprite) Untrack() {
+	if s.index == nil {
+		return
+	}
+	s.index.Remove(s)
+	s.index = nil
+}
+
+// Center returns the center of the sprite.
+func (s *CharacterSprite) Center() image.Point {
+	return image.Pt(s.X+s.Width/2, s.Y+s.Height/2)
+}
+
+// SetPosition moves the sprite to x, y.
+func (s *CharacterSprite) SetPosition(x, y int) {
+	s.X, s.Y = x, y
+	s.updateIndex()
+}
+
+func (s *CharacterSprite) updateIndex() {
+	if s.index != nil {
+		s.index.Update(s, s.Center())
+	}
+}
+
+// Hitbox returns the shape the sprite touches other sprites with.
+func (s *CharacterSprite) Hitbox() Shape {
+	if a := s.currentAnimation(); a != nil && a.Hitboxes.Hitbox != nil {
+		return a.Hitboxes.Hitbox
+	}
+	return s.spriteHitbox()
+}
+
+// spriteHitbox returns the hitbox of the sprite, ignoring its animations.
+func (s *CharacterSprite) spriteHitbox() Shape {
+	if s.Hitboxes.Hitbox != nil {
+		return s.Hitboxes.Hitbox
+	}
+	return Circle{Center: image.Pt(s.Width/2, s.Height/2), Radius: s.Width / 4}
+}
+
+// Hurtbox returns the shape the sprite is hurt in.
+func (s *CharacterSprite) Hurtbox() Shape {
+	if a := s.currentAnimation(); a != nil && a.Hitboxes.Hurtbox != nil {
+		return a.Hitboxes.Hurtbox
+	}
+	if s.Hitboxes.Hurtbox != nil {
+		return s.Hitboxes.Hurtbox
+	}
+	return s.Hitbox()
+}
+
+// Pickup returns the shape the sprite picks up items with.
+func (s *CharacterSprite) Pickup() Shape {
+	if a := s.currentAnimation(); a != nil && a.Hitboxes.Pickup != nil {
+		return a.Hitboxes.Pickup
+	}
+	if s.Hitboxes.Pickup != nil {
+		return s.Hitboxes.Pickup
+	}
+	return s.Hitbox()
+}
+
+func (s *CharacterSprite) currentAnimation() *Animation {
+	if s.CurrentAnimation < len(s.Animations) {
+		return &s.Animations[s.CurrentAnimation]
+	}
+	return nil
+}
+
+// CheckCollision reports whether the hitboxes of the sprites overlap.
 func (s *CharacterSprite) CheckCollision(sprite *CharacterSprite) bool {
-	ownCenter := image.Point{X: s.X + s.Width/2, Y: s.Y + s.Height/2}
-	spriteCenter := image.Point{X: sprite.X + sprite.Width/2, Y: sprite.Y + sprite.Height/2}
-	distanceX := ownCenter.X - spriteCenter.X
-	distanceY := ownCenter.Y - spriteCenter.Y
-	distance := math.Sqrt(float64(distanceX*distanceX) + float64(distanceY*distanceY))
+	return Overlaps(s.Hitbox(), s.position(), sprite.Hitbox(), sprite.position())
+}
+
+// HurtBy reports whether the hitbox of the enemy overlaps the hurtbox of s.
+func (s *CharacterSprite) HurtBy(enemy *CharacterSprite) bool {
+	return Overlaps(s.Hurtbox(), s.position(), enemy.Hitbox(), enemy.position())
+}
 
-	return distance < float64(s.Width)/2
+// PicksUp reports whether the pickup shape of s overlaps the hitbox of the item.
+func (s *CharacterSprite) PicksUp(item *CharacterSprite) bool {
+	return Overlaps(s.Pickup(), s.position(), item.Hitbox(), item.position())
 }
 
-type Animation struct {
-	Name   string
-	Frames int
+func (s *CharacterSprite) position() image.Point {
+	return image.Pt(s.X, s.Y)
+}
+
+// Reach returns how far the hitbox of the sprite extends from its center, in
+// any of its animations.
+func (s *CharacterSprite) Reach() int {
+	center := image.Pt(s.Width/2, s.Height/2)
+	r := reach(s.spriteHitbox(), center)
+	for _, a := range s.Animations {
+		r = max(r, reach(a.Hitboxes.Hitbox, center))
+	}
+	return r
+}
+
+// CollisionArea returns the area the centers of sprites reaching at most
+// reach from their center are in, if their hitbox can overlap shape of s. It
+// is used to query an index.
+func (s *CharacterSprite) CollisionArea(shape Shape, reach int) image.Rectangle {
+	return shape.Bounds().Add(s.position()).Inset(-reach - 1)
 }
 
 func NewCharacterSprite(img *ebiten.Image, width, height int, animations []Animation, id SpriteId) *CharacterSprite {
@@ -89,9 +211,11 @@ func NewPlayerSprite(img *ebiten.Image, width, height int, animations []Animatio
 	return s
 }
 
+// SetNextAnimation queues the animation to play once the player turns.
+// Like SetAnimation, it matches names case-insensitively.
 func (s *Player) SetNextAnimation(animation string) {
 	for i, anim := range s.Animations {
-		if anim.Name == animation {
+		if strings.EqualFold(anim.Name, animation) {
 			s.NextAnimation = i
 			return
 		}
@@ -99,12 +223,14 @@ func (s *Player) SetNextAnimation(animation string) {
 
 }
 
+// SetAnimation switches to the named animation. Names are matched
+// case-insensitively, as Aseprite tags aren't consistently cased.
 func (s *CharacterSprite) SetAnimation(animation string) {
 	for i, anim := range s.Animations {
-		if anim.Name == animation {
+		if strings.EqualFold(anim.Name, animation) {
 			if s.CurrentAnimation != i {
 				s.CurrentAnimation = i
-				s.CurrentFrame = 0
+				s.ResetAnimation()
 			}
 			return
 		}
@@ -130,13 +256,7 @@ func (s *CharacterSprite) Move(screenWidth, screenHeight int) {
 	if s.Y > screenHeight-s.Height {
 		s.Y = screenHeight - s.Height
 	}
-}
-
-func (s *CharacterSprite) Animate() {
-	s.CurrentFrame++
-	if s.CurrentFrame >= s.Animations[s.CurrentAnimation].Frames {
-		s.CurrentFrame = 0
-	}
+	s.updateIndex()
 }
 
 func (s *CharacterSprite) GetCurrentImage() *ebiten.Image {
@@ -0,0 +1,383 @@
+// Package tracker plays DefleMask modules (.dmf). Modules are synthesized in
+// real time, the chips they were written for are approximated: the FM channels
+// of the Sega Genesis and the PSG channels of the Genesis and Master System.
+package tracker
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const magic = ".DelekDefleMask."
+
+// minVersion is the oldest file version that can be read, the one of DefleMask 0.12.
+const minVersion = 0x18
+
+// Sizes read from a module are checked against these limits before anything
+// is allocated for them. DefleMask doesn't save larger modules.
+const (
+	maxModuleSize     = 16 << 20
+	maxRowsPerPattern = 256
+	maxWavetableSize  = 256
+)
+
+// System is the console a module was written for.
+type System byte
+
+const (
+	SystemGenesis System = 0x02
+	SystemSMS     System = 0x03
+)
+
+// Channel counts of the supported systems. The Genesis has 6 FM and 4 PSG channels.
+var systemChannels = map[System]int{
+	SystemGenesis: 10,
+	SystemSMS:     4,
+}
+
+// fmChannels is the number of FM channels of the system, they come first.
+func (s System) fmChannels() int {
+	if s == SystemGenesis {
+		return 6
+	}
+	return 0
+}
+
+// Note values with a special meaning.
+const (
+	NoteEmpty = 0
+	NoteOff   = 100
+)
+
+// Module is a parsed DefleMask module.
+type Module struct {
+	Version byte
+	System  System
+	Name    string
+	Author  string
+	// TimeBase multiplies the ticks per row.
+	TimeBase int
+	// Speed1 and Speed2 are the ticks of even and odd rows.
+	Speed1 int
+	Speed2 int
+	// TickRate is the number of ticks per second.
+	TickRate float64
+	// RowsPerPattern is the length of every pattern.
+	RowsPerPattern int
+	// Orders is the number of rows in the pattern matrix.
+	Orders      int
+	Instruments []Instrument
+	Channels    []Channel
+}
+
+// Channel holds the patterns of one channel, in the order they are played.
+type Channel struct {
+	EffectColumns int
+	// Patterns has one pattern per row of the pattern matrix.
+	Patterns [][]Row
+}
+
+// Row is a row of a pattern. Unset values are -1.
+type Row struct {
+	// Note is 1 (C#) to 12 (C of the next octave), NoteEmpty or NoteOff.
+	Note       int
+	Octave     int
+	Volume     int
+	Effects    []Effect
+	Instrument int
+}
+
+type Effect struct {
+	Code  int
+	Value int
+}
+
+// Instrument is either an FM instrument or a standard one for the PSG.
+type Instrument struct {
+	Name string
+	FM   *FMInstrument
+	STD  *STDInstrument
+}
+
+// FMInstrument is a patch of the YM2612.
+type FMInstrument struct {
+	Algorithm int
+	Feedback  int
+	FMS       int
+	AMS       int
+	Operators [4]Operator
+}
+
+type Operator struct {
+	AM      int
+	AR      int
+	DR      int
+	Mult    int
+	RR      int
+	SL      int
+	TL      int
+	DT2     int
+	RS      int
+	DT      int
+	D2R     int
+	SSGMode int
+}
+
+// STDInstrument changes the volume and note of a PSG channel every tick.
+type STDInstrument struct {
+	Volume        Macro
+	Arpeggio      Macro
+	Duty          Macro
+	Wave          Macro
+	FixedArpeggio bool
+}
+
+// Macro is a value per tick. It repeats from Loop once it ended, if Loop isn't -1.
+type Macro struct {
+	Values []int
+	Loop   int
+}
+
+// Parse reads a DefleMask module.
+func Parse(r io.Reader) (*Module, error) {
+	z, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress module: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(z, maxModuleSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress module: %w", err)
+	}
+	if len(data) > maxModuleSize {
+		return nil, fmt.Errorf("module is larger than %d bytes", maxModuleSize)
+	}
+	p := &parser{r: bytes.NewReader(data)}
+	m, err := p.module()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse module: %w", err)
+	}
+	return m, nil
+}
+
+// parser reads the values of a module. The first error is kept, and all
+// reads after it return zero.
+type parser struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (p *parser) read(v any) {
+	if p.err != nil {
+		return
+	}
+	p.err = binary.Read(p.r, binary.LittleEndian, v)
+	if errors.Is(p.err, io.EOF) {
+		p.err = io.ErrUnexpectedEOF
+	}
+}
+
+func (p *parser) byte() int {
+	var v uint8
+	p.read(&v)
+	return int(v)
+}
+
+func (p *parser) int8() int {
+	var v int8
+	p.read(&v)
+	return int(v)
+}
+
+func (p *parser) int16() int {
+	var v int16
+	p.read(&v)
+	return int(v)
+}
+
+func (p *parser) int32() int {
+	var v int32
+	p.read(&v)
+	return int(v)
+}
+
+func (p *parser) bytes(n int) []byte {
+	if p.err == nil && (n < 0 || n > p.r.Len()) {
+		p.err = io.ErrUnexpectedEOF
+	}
+	if p.err != nil {
+		return nil
+	}
+	b := make([]byte, n)
+	p.read(b)
+	return b
+}
+
+func (p *parser) string() string {
+	return string(p.bytes(p.byte()))
+}
+
+func (p *parser) module() (*Module, error) {
+	if string(p.bytes(len(magic))) != magic {
+		return nil, fmt.Errorf("not a DefleMask module")
+	}
+	m := &Module{}
+	m.Version = byte(p.byte())
+	if p.err == nil && m.Version < minVersion {
+		return nil, fmt.Errorf("unsupported version %#x, save it with a newer DefleMask", m.Version)
+	}
+	m.System = System(p.byte())
+	channels, ok := systemChannels[m.System]
+	if p.err == nil && !ok {
+		return nil, fmt.Errorf("unsupported system %#x", byte(m.System))
+	}
+	m.Name = p.string()
+	m.Author = p.string()
+	// Highlighted rows are only relevant for the editor.
+	p.bytes(2)
+	m.TimeBase = p.byte()
+	m.Speed1 = p.byte()
+	m.Speed2 = p.byte()
+	ntsc := p.byte() == 1
+	customRate := p.byte() == 1
+	rate := strings.TrimRight(string(p.bytes(3)), "\x00")
+	m.TickRate = 50
+	if ntsc {
+		m.TickRate = 60
+	}
+	if customRate {
+		hz, err := strconv.Atoi(rate)
+		if err != nil || hz <= 0 {
+			return nil, fmt.Errorf("invalid tick rate %q", rate)
+		}
+		m.TickRate = float64(hz)
+	}
+	m.RowsPerPattern = p.int32()
+	m.Orders = p.byte()
+	if p.err == nil && (m.RowsPerPattern <= 0 || m.RowsPerPattern > maxRowsPerPattern) {
+		return nil, fmt.Errorf("invalid number of rows per pattern %d", m.RowsPerPattern)
+	}
+	if p.err == nil && m.Orders == 0 {
+		return nil, fmt.Errorf("module has no patterns")
+	}
+
+	matrix := make([][]int, channels)
+	for c := range matrix {
+		matrix[c] = make([]int, m.Orders)
+		for o := range matrix[c] {
+			matrix[c][o] = p.byte()
+			if m.Version > 0x18 {
+				// Pattern names were added in DefleMask 0.12.1.
+				p.string()
+			}
+		}
+	}
+
+	instruments := p.byte()
+	for range instruments {
+		m.Instruments = append(m.Instruments, p.instrument())
+	}
+
+	wavetables := p.byte()
+	for range wavetables {
+		size := p.int32()
+		if p.err == nil && (size < 0 || size > maxWavetableSize) {
+			return nil, fmt.Errorf("invalid wavetable size %d", size)
+		}
+		p.bytes(4 * size)
+	}
+
+	m.Channels = make([]Channel, channels)
+	for c := range m.Channels {
+		ch := &m.Channels[c]
+		ch.EffectColumns = p.byte()
+		for range m.Orders {
+			ch.Patterns = append(ch.Patterns, p.pattern(m.RowsPerPattern, ch.EffectColumns))
+		}
+	}
+	// PCM samples for the DAC follow, they aren't played.
+	if p.err != nil {
+		return nil, p.err
+	}
+	return m, nil
+}
+
+func (p *parser) instrument() Instrument {
+	i := Instrument{Name: p.string()}
+	if p.byte() == 1 {
+		fm := &FMInstrument{
+			Algorithm: p.byte(),
+			Feedback:  p.byte(),
+			FMS:       p.byte(),
+			AMS:       p.byte(),
+		}
+		for op := range fm.Operators {
+			fm.Operators[op] = Operator{
+				AM:      p.byte(),
+				AR:      p.byte(),
+				DR:      p.byte(),
+				Mult:    p.byte(),
+				RR:      p.byte(),
+				SL:      p.byte(),
+				TL:      p.byte(),
+				DT2:     p.byte(),
+				RS:      p.byte(),
+				DT:      p.byte(),
+				D2R:     p.byte(),
+				SSGMode: p.byte(),
+			}
+		}
+		i.FM = fm
+		return i
+	}
+	std := &STDInstrument{}
+	std.Volume = p.macro()
+	std.Arpeggio = p.macro()
+	std.FixedArpeggio = p.byte() == 1
+	std.Duty = p.macro()
+	std.Wave = p.macro()
+	i.STD = std
+	return i
+}
+
+func (p *parser) macro() Macro {
+	m := Macro{Values: make([]int, p.byte()), Loop: -1}
+	for i := range m.Values {
+		m.Values[i] = p.int32()
+	}
+	if len(m.Values) > 0 {
+		m.Loop = p.int8()
+	}
+	return m
+}
+
+func (p *parser) pattern(rows, effectColumns int) []Row {
+	// Every row has 4 values and 2 per effect, of 2 bytes each. A truncated
+	// module fails before the rows are allocated.
+	if p.err == nil && rows*(4+2*effectColumns)*2 > p.r.Len() {
+		p.err = io.ErrUnexpectedEOF
+	}
+	if p.err != nil {
+		return nil
+	}
+	pattern := make([]Row, rows)
+	for i := range pattern {
+		row := &pattern[i]
+		row.Note = p.int16()
+		row.Octave = p.int16()
+		row.Volume = p.int16()
+		for range effectColumns {
+			e := Effect{Code: p.int16(), Value: p.int16()}
+			if e.Code != -1 {
+				row.Effects = append(row.Effects, e)
+			}
+		}
+		row.Instrument = p.int16()
+	}
+	return pattern
+}
@@ -0,0 +1,156 @@
+package tracker
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+// testModule describes a Master System module without instruments, which
+// encode writes like DefleMask does. Lengths beyond the limits of the parser
+// are written as is, but with no more data than the limits allow.
+type testModule struct {
+	rowsPerPattern int32
+	orders         uint8
+	wavetables     []int32
+	// notes are played by the first channel, one per row.
+	notes []int16
+}
+
+func (tm testModule) encode(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	write := func(v any) {
+		if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeString := func(s string) {
+		write(uint8(len(s)))
+		buf.WriteString(s)
+	}
+	buf.WriteString(magic)
+	write([]uint8{minVersion, uint8(SystemSMS)})
+	writeString("test")
+	writeString("tester")
+	// Highlights, time base, speeds, NTSC, custom rate and the rate.
+	write([]uint8{4, 16, 0, 1, 1, 1, 0, 0, 0, 0})
+	write(tm.rowsPerPattern)
+	write(tm.orders)
+	channels := systemChannels[SystemSMS]
+	for range channels * int(tm.orders) {
+		write(uint8(0))
+	}
+	write(uint8(0))
+	write(uint8(len(tm.wavetables)))
+	for _, size := range tm.wavetables {
+		write(size)
+		for range min(max(size, 0), maxWavetableSize) {
+			write(int32(0))
+		}
+	}
+	for c := range channels {
+		// One effect column per channel.
+		write(uint8(1))
+		for o := range int(tm.orders) {
+			for r := range int(min(max(tm.rowsPerPattern, 0), maxRowsPerPattern)) {
+				note, octave := int16(NoteEmpty), int16(-1)
+				if i := o*int(tm.rowsPerPattern) + r; c == 0 && i < len(tm.notes) {
+					note, octave = tm.notes[i], 4
+				}
+				write([]int16{note, octave, -1, -1, -1, -1})
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func compress(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	z := zlib.NewWriter(&buf)
+	if _, err := z.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	tm := testModule{rowsPerPattern: 4, orders: 2, wavetables: []int32{32}, notes: []int16{1, 5, NoteOff}}
+	m, err := Parse(bytes.NewReader(compress(t, tm.encode(t))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "test" || m.Author != "tester" || m.System != SystemSMS {
+		t.Errorf("module is %q by %q for system %#x, want test by tester for the Master System", m.Name, m.Author, m.System)
+	}
+	if m.TickRate != 60 || m.Speed1 != 1 || m.Speed2 != 1 {
+		t.Errorf("tick rate is %v with speeds %d and %d, want 60 with speeds 1 and 1", m.TickRate, m.Speed1, m.Speed2)
+	}
+	if m.RowsPerPattern != 4 || m.Orders != 2 || len(m.Channels) != 4 {
+		t.Fatalf("module has %d orders of %d rows on %d channels, want 2 orders of 4 rows on 4 channels", m.Orders, m.RowsPerPattern, len(m.Channels))
+	}
+	rows := m.Channels[0].Patterns[0]
+	if len(rows) != 4 || rows[0].Note != 1 || rows[1].Note != 5 || rows[2].Note != NoteOff || rows[3].Note != NoteEmpty {
+		t.Errorf("rows of the first pattern are %+v", rows)
+	}
+	if len(rows[0].Effects) != 0 || rows[0].Instrument != -1 {
+		t.Errorf("unset effects and instrument are read as %+v", rows[0])
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	valid := testModule{rowsPerPattern: 4, orders: 2, wavetables: []int32{8}}.encode(t)
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"not a module", []byte("hello")},
+		{"negative rows per pattern", testModule{rowsPerPattern: -1, orders: 1}.encode(t)},
+		{"no rows per pattern", testModule{rowsPerPattern: 0, orders: 1}.encode(t)},
+		{"too many rows per pattern", testModule{rowsPerPattern: 1 << 30, orders: 1}.encode(t)},
+		{"no orders", testModule{rowsPerPattern: 4, orders: 0}.encode(t)},
+		{"negative wavetable size", testModule{rowsPerPattern: 4, orders: 1, wavetables: []int32{-1}}.encode(t)},
+		{"too large wavetable", testModule{rowsPerPattern: 4, orders: 1, wavetables: []int32{1 << 29}}.encode(t)},
+	}
+	// Every truncation of a valid module fails.
+	for n := range len(valid) {
+		tests = append(tests, struct {
+			name string
+			data []byte
+		}{"truncated", valid[:n]})
+	}
+	for _, tt := range tests {
+		_, err := Parse(bytes.NewReader(compress(t, tt.data)))
+		if err == nil {
+			t.Errorf("%s module of %d bytes was parsed", tt.name, len(tt.data))
+		}
+	}
+
+	_, err := Parse(bytes.NewReader(valid))
+	if err == nil {
+		t.Error("uncompressed module was parsed")
+	}
+}
+
+func TestParseShippedModules(t *testing.T) {
+	for _, name := range []string{"backgroundmusic_1", "gameover"} {
+		data, err := os.ReadFile("../../assets/sfx/" + name + ".dmf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := Parse(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("failed to parse %s: %v", name, err)
+			continue
+		}
+		if m.System != SystemGenesis || len(m.Channels) != 10 {
+			t.Errorf("%s is for system %#x with %d channels, want the Genesis with 10", name, m.System, len(m.Channels))
+		}
+	}
+}
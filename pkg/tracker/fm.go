@@ -0,0 +1,215 @@
+package tracker
+
+import "math"
+
+const (
+	// maxAttenuation is the envelope level in dB at which an operator is silent.
+	maxAttenuation = 96.0
+	// modulationDepth is the phase change in radians of an operator at full output.
+	modulationDepth = 2 * math.Pi
+)
+
+// decayTime returns the seconds an envelope with the YM2612 rate takes to
+// fall by maxAttenuation. Rates go from 1 (slowest) to 31, 0 never decays.
+func decayTime(rate int) float64 {
+	if rate <= 0 {
+		return math.Inf(1)
+	}
+	// Two rates halve the time, the fastest one takes about 7ms.
+	return 0.0067 * math.Exp2(float64(62-2*min(rate, 31))/4)
+}
+
+type envelopeStage int
+
+const (
+	stageAttack envelopeStage = iota
+	stageDecay
+	stageSustain
+	stageRelease
+)
+
+// fmOperator is an operator of an FM channel, a sine wave with an envelope.
+type fmOperator struct {
+	Operator
+	phase float64
+	// level is the attenuation of the envelope in dB.
+	level float64
+	stage envelopeStage
+	// out and prevOut are the last outputs, for feedback.
+	out, prevOut float64
+}
+
+func (op *fmOperator) keyOn() {
+	op.stage = stageAttack
+	op.phase = 0
+}
+
+func (op *fmOperator) keyOff() {
+	op.stage = stageRelease
+}
+
+// advanceEnvelope moves the envelope by dt seconds.
+func (op *fmOperator) advanceEnvelope(dt float64) {
+	switch op.stage {
+	case stageAttack:
+		// Attacks are much faster than decays at the same rate.
+		op.level -= maxAttenuation / (decayTime(op.AR) / 8) * dt
+		if op.AR >= 31 || op.level <= 0 {
+			op.level = 0
+			op.stage = stageDecay
+		}
+	case stageDecay:
+		sustain := float64(op.SL) * 3
+		if op.SL == 15 {
+			sustain = maxAttenuation
+		}
+		op.level += maxAttenuation / decayTime(op.DR) * dt
+		if op.level >= sustain {
+			op.level = sustain
+			op.stage = stageSustain
+		}
+	case stageSustain:
+		op.level += maxAttenuation / decayTime(op.D2R) * dt
+	case stageRelease:
+		op.level += maxAttenuation / decayTime(op.RR*2+1) * dt
+	}
+	op.level = min(op.level, maxAttenuation)
+}
+
+// output advances the operator by dt seconds at freq and returns its
+// output, phase modulated by mod.
+func (op *fmOperator) output(freq, mod, attenuation, dt float64) float64 {
+	mult := float64(op.Mult)
+	if op.Mult == 0 {
+		mult = 0.5
+	}
+	op.phase += 2 * math.Pi * freq * mult * dt
+	if op.phase > 2*math.Pi {
+		op.phase -= 2 * math.Pi
+	}
+	op.advanceEnvelope(dt)
+	db := op.level + float64(op.TL)*0.75 + attenuation
+	if db >= maxAttenuation {
+		op.prevOut, op.out = op.out, 0
+		return 0
+	}
+	out := math.Sin(op.phase+mod*modulationDepth) * math.Exp(-db*math.Ln10/20)
+	op.prevOut, op.out = op.out, out
+	return out
+}
+
+// carriers lists the operators heard for every algorithm, the others only
+// modulate.
+var carriers = [8][4]bool{
+	{false, false, false, true},
+	{false, false, false, true},
+	{false, false, false, true},
+	{false, false, false, true},
+	{false, true, false, true},
+	{false, true, true, true},
+	{false, true, true, true},
+	{true, true, true, true},
+}
+
+// fmVoice approximates an FM channel of the YM2612.
+type fmVoice struct {
+	instrument *FMInstrument
+	ops        [4]fmOperator
+	freq       float64
+	// attenuation in dB lowers the carriers, set from the channel volume.
+	attenuation float64
+}
+
+// newFMVoice returns a voice that is silent until the first note.
+func newFMVoice() *fmVoice {
+	v := &fmVoice{}
+	for n := range v.ops {
+		v.ops[n].stage = stageRelease
+		v.ops[n].level = maxAttenuation
+	}
+	return v
+}
+
+func (v *fmVoice) setInstrument(i *FMInstrument) {
+	v.instrument = i
+	for n := range v.ops {
+		v.ops[n].Operator = i.Operators[n]
+	}
+}
+
+func (v *fmVoice) noteOn(freq float64) {
+	v.freq = freq
+	for n := range v.ops {
+		v.ops[n].keyOn()
+	}
+}
+
+func (v *fmVoice) noteOff() {
+	for n := range v.ops {
+		v.ops[n].keyOff()
+	}
+}
+
+// setVolume sets the channel volume from 0 to 127.
+func (v *fmVoice) setVolume(volume int) {
+	v.attenuation = float64(127-min(max(volume, 0), 127)) * 0.75
+}
+
+// silent reports whether all operators were released and faded out.
+func (v *fmVoice) silent() bool {
+	for _, op := range v.ops {
+		if op.stage != stageRelease || op.level < maxAttenuation {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *fmVoice) sample(dt float64) float64 {
+	if v.instrument == nil || v.silent() {
+		return 0
+	}
+	alg := v.instrument.Algorithm & 7
+	att := func(n int) float64 {
+		if carriers[alg][n] {
+			return v.attenuation
+		}
+		return 0
+	}
+	op1, op2, op3, op4 := &v.ops[0], &v.ops[1], &v.ops[2], &v.ops[3]
+	feedback := 0.0
+	if v.instrument.Feedback > 0 {
+		feedback = (op1.out + op1.prevOut) / 2 * math.Exp2(float64(v.instrument.Feedback)-7)
+	}
+	o1 := op1.output(v.freq, feedback, att(0), dt)
+	var out float64
+	switch alg {
+	case 0:
+		o2 := op2.output(v.freq, o1, att(1), dt)
+		o3 := op3.output(v.freq, o2, att(2), dt)
+		out = op4.output(v.freq, o3, att(3), dt)
+	case 1:
+		o2 := op2.output(v.freq, 0, att(1), dt)
+		o3 := op3.output(v.freq, o1+o2, att(2), dt)
+		out = op4.output(v.freq, o3, att(3), dt)
+	case 2:
+		o2 := op2.output(v.freq, 0, att(1), dt)
+		o3 := op3.output(v.freq, o2, att(2), dt)
+		out = op4.output(v.freq, o1+o3, att(3), dt)
+	case 3:
+		o2 := op2.output(v.freq, o1, att(1), dt)
+		o3 := op3.output(v.freq, 0, att(2), dt)
+		out = op4.output(v.freq, o2+o3, att(3), dt)
+	case 4:
+		o2 := op2.output(v.freq, o1, att(1), dt)
+		o3 := op3.output(v.freq, 0, att(2), dt)
+		out = o2 + op4.output(v.freq, o3, att(3), dt)
+	case 5:
+		out = op2.output(v.freq, o1, att(1), dt) + op3.output(v.freq, o1, att(2), dt) + op4.output(v.freq, o1, att(3), dt)
+	case 6:
+		out = op2.output(v.freq, o1, att(1), dt) + op3.output(v.freq, 0, att(2), dt) + op4.output(v.freq, 0, att(3), dt)
+	case 7:
+		out = o1 + op2.output(v.freq, 0, att(1), dt) + op3.output(v.freq, 0, att(2), dt) + op4.output(v.freq, 0, att(3), dt)
+	}
+	return out
+}
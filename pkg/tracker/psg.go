@@ -0,0 +1,56 @@
+package tracker
+
+import "math"
+
+// psgVoice approximates a channel of the SN76489, a square wave or noise.
+type psgVoice struct {
+	noise  bool
+	freq   float64
+	phase  float64
+	on     bool
+	volume int
+	// lfsr generates the noise.
+	lfsr uint16
+}
+
+func (v *psgVoice) noteOn(freq float64) {
+	v.freq = freq
+	v.on = true
+}
+
+func (v *psgVoice) noteOff() {
+	v.on = false
+}
+
+// setVolume sets the volume from 0 to 15.
+func (v *psgVoice) setVolume(volume int) {
+	v.volume = min(max(volume, 0), 15)
+}
+
+func (v *psgVoice) sample(dt float64) float64 {
+	if !v.on || v.volume == 0 {
+		return 0
+	}
+	// Every step of the volume is 2dB.
+	amp := math.Pow(10, -float64(15-v.volume)*2/20)
+	v.phase += v.freq * dt
+	if !v.noise {
+		v.phase -= math.Floor(v.phase)
+		if v.phase < 0.5 {
+			return amp
+		}
+		return -amp
+	}
+	for v.phase >= 1 {
+		v.phase--
+		if v.lfsr == 0 {
+			v.lfsr = 0x4000
+		}
+		bit := (v.lfsr ^ v.lfsr>>1) & 1
+		v.lfsr = v.lfsr>>1 | bit<<14
+	}
+	if v.lfsr&1 == 1 {
+		return amp
+	}
+	return -amp
+}
@@ -0,0 +1,263 @@
+package tracker
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+const (
+	// bytesPerFrame is the size of a stereo frame of 16 bit samples.
+	bytesPerFrame = 4
+	// fmGain and psgGain balance the channels, so all of them together don't clip.
+	fmGain  = 0.25
+	psgGain = 0.15
+)
+
+// Effects that are played. Others are ignored.
+const (
+	effectPanning     = 0x08
+	effectSpeed1      = 0x09
+	effectVolumeSlide = 0x0A
+	effectJump        = 0x0B
+	effectBreak       = 0x0D
+	effectSpeed2      = 0x0F
+)
+
+// voice is a channel of a sound chip.
+type voice interface {
+	noteOn(freq float64)
+	noteOff()
+	setVolume(volume int)
+	sample(dt float64) float64
+}
+
+type channel struct {
+	voice
+	fm         *fmVoice
+	instrument *Instrument
+	// note is the semitone played, counting from C-0. -1 if none.
+	note        int
+	volume      int
+	maxVolume   int
+	volumeSlide int
+	left, right bool
+	// macroTick is the tick of the instrument macros since the note started.
+	macroTick int
+}
+
+// noteFrequency returns the frequency of a semitone, A-4 is 440Hz.
+func noteFrequency(semitone int) float64 {
+	return 440 * math.Exp2(float64(semitone-57)/12)
+}
+
+// Stream synthesizes a module as 16 bit stereo PCM, ready for audio.NewPlayer.
+type Stream struct {
+	module     *Module
+	sampleRate float64
+	loop       bool
+	channels   []*channel
+
+	order, row, tick int
+	speeds           [2]int
+	// jump and breakRow are set by the effects of the current row, -1 if unset.
+	jump, breakRow int
+	// samplesToTick counts down the samples until the next tick.
+	samplesToTick float64
+	ended         bool
+}
+
+// NewStream plays the module at the sample rate. If loop is set, the module
+// starts over after it ended, otherwise the stream ends with it.
+func NewStream(m *Module, sampleRate int, loop bool) *Stream {
+	s := &Stream{
+		module:     m,
+		sampleRate: float64(sampleRate),
+		loop:       loop,
+		speeds:     [2]int{max(m.Speed1, 1), max(m.Speed2, 1)},
+		jump:       -1,
+		breakRow:   -1,
+	}
+	fmChannels := m.System.fmChannels()
+	for c := range m.Channels {
+		ch := &channel{note: -1, left: true, right: true}
+		switch {
+		case c < fmChannels:
+			ch.fm = newFMVoice()
+			ch.voice = ch.fm
+			ch.maxVolume = 127
+		default:
+			// The last PSG channel is the noise channel.
+			ch.voice = &psgVoice{noise: c == len(m.Channels)-1}
+			ch.maxVolume = 15
+		}
+		ch.volume = ch.maxVolume
+		ch.setVolume(ch.volume)
+		s.channels = append(s.channels, ch)
+	}
+	return s
+}
+
+func (s *Stream) Read(p []byte) (int, error) {
+	n := 0
+	dt := 1 / s.sampleRate
+	for n+bytesPerFrame <= len(p) {
+		if s.samplesToTick <= 0 {
+			if s.ended {
+				break
+			}
+			s.nextTick()
+			s.samplesToTick += s.sampleRate / s.module.TickRate
+			continue
+		}
+		var left, right float64
+		for _, ch := range s.channels {
+			gain := psgGain
+			if ch.fm != nil {
+				gain = fmGain
+			}
+			v := ch.sample(dt) * gain
+			if ch.left {
+				left += v
+			}
+			if ch.right {
+				right += v
+			}
+		}
+		binary.LittleEndian.PutUint16(p[n:], uint16(toInt16(left)))
+		binary.LittleEndian.PutUint16(p[n+2:], uint16(toInt16(right)))
+		n += bytesPerFrame
+		s.samplesToTick--
+	}
+	if n == 0 && s.ended {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+func toInt16(v float64) int16 {
+	return int16(min(max(v, -1), 1) * math.MaxInt16)
+}
+
+// nextTick plays the rows when they start and runs the effects and macros of
+// the current tick.
+func (s *Stream) nextTick() {
+	if s.tick == 0 {
+		for c, ch := range s.channels {
+			s.playRow(ch, s.module.Channels[c].Patterns[s.order][s.row])
+		}
+	}
+	for _, ch := range s.channels {
+		s.tickChannel(ch)
+	}
+
+	s.tick++
+	if s.tick < s.speeds[s.row%2]*(s.module.TimeBase+1) {
+		return
+	}
+	s.tick = 0
+	s.advance()
+}
+
+// advance moves to the next row, following jumps and breaks.
+func (s *Stream) advance() {
+	order, row := s.order, s.row+1
+	switch {
+	case s.jump >= 0:
+		order, row = s.jump, 0
+	case s.breakRow >= 0:
+		order, row = s.order+1, s.breakRow
+	}
+	if row >= s.module.RowsPerPattern {
+		order, row = order+1, 0
+	}
+	// Jumping back is how modules loop.
+	looped := s.jump >= 0 && s.jump <= s.order
+	s.jump, s.breakRow = -1, -1
+	if order >= s.module.Orders {
+		order, looped = 0, true
+	}
+	if looped && !s.loop {
+		s.ended = true
+		return
+	}
+	s.order, s.row = order, min(row, s.module.RowsPerPattern-1)
+}
+
+func (s *Stream) playRow(ch *channel, row Row) {
+	if row.Instrument >= 0 && row.Instrument < len(s.module.Instruments) {
+		ch.instrument = &s.module.Instruments[row.Instrument]
+		if ch.fm != nil && ch.instrument.FM != nil {
+			ch.fm.setInstrument(ch.instrument.FM)
+		}
+	}
+	if row.Volume >= 0 {
+		ch.volume = min(row.Volume, ch.maxVolume)
+		ch.setVolume(ch.volume)
+	}
+	for _, e := range row.Effects {
+		switch e.Code {
+		case effectPanning:
+			ch.left, ch.right = e.Value&0xF0 != 0, e.Value&0x0F != 0
+		case effectSpeed1:
+			s.speeds[0] = max(e.Value, 1)
+		case effectSpeed2:
+			s.speeds[1] = max(e.Value, 1)
+		case effectVolumeSlide:
+			ch.volumeSlide = e.Value>>4 - e.Value&0x0F
+		case effectJump:
+			s.jump = e.Value
+		case effectBreak:
+			s.breakRow = e.Value
+		}
+	}
+	switch {
+	case row.Note == NoteOff:
+		ch.noteOff()
+		ch.note = -1
+	case row.Note == NoteEmpty && row.Octave == 0:
+	default:
+		ch.note = row.Octave*12 + row.Note
+		ch.macroTick = 0
+		ch.noteOn(noteFrequency(ch.note))
+	}
+}
+
+// tickChannel runs the volume slide and the macros of the instrument.
+func (s *Stream) tickChannel(ch *channel) {
+	if ch.volumeSlide != 0 && s.tick > 0 {
+		ch.volume = min(max(ch.volume+ch.volumeSlide, 0), ch.maxVolume)
+		ch.setVolume(ch.volume)
+	}
+	if ch.instrument == nil || ch.instrument.STD == nil || ch.note < 0 {
+		return
+	}
+	std := ch.instrument.STD
+	if v, ok := std.Volume.at(ch.macroTick); ok {
+		ch.setVolume(v * ch.volume / ch.maxVolume)
+	}
+	if v, ok := std.Arpeggio.at(ch.macroTick); ok {
+		note := ch.note + v
+		if std.FixedArpeggio {
+			note = v
+		}
+		if psg, ok := ch.voice.(*psgVoice); ok {
+			psg.freq = noteFrequency(note)
+		}
+	}
+	ch.macroTick++
+}
+
+// at returns the value of the macro at the tick, and false if it is empty.
+func (m Macro) at(tick int) (int, bool) {
+	if len(m.Values) == 0 {
+		return 0, false
+	}
+	if tick >= len(m.Values) {
+		if m.Loop < 0 || m.Loop >= len(m.Values) {
+			return m.Values[len(m.Values)-1], true
+		}
+		tick = m.Loop + (tick-m.Loop)%(len(m.Values)-m.Loop)
+	}
+	return m.Values[tick], true
+}
@@ -0,0 +1,78 @@
+package tracker
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// duration returns how long the stream plays at the sample rate.
+func duration(t *testing.T, s *Stream, sampleRate int) time.Duration {
+	t.Helper()
+	n, err := io.Copy(io.Discard, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return time.Duration(n/bytesPerFrame) * time.Second / time.Duration(sampleRate)
+}
+
+func TestStreamEnds(t *testing.T) {
+	tm := testModule{rowsPerPattern: 4, orders: 2, notes: []int16{1, 3, 5, 8, 1, NoteOff}}
+	m, err := Parse(bytes.NewReader(compress(t, tm.encode(t))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 8 rows of one tick each, at 60 ticks per second.
+	if got, want := duration(t, NewStream(m, 6000, false), 6000), 8*time.Second/60; got != want {
+		t.Errorf("module plays for %v, want %v", got, want)
+	}
+}
+
+func TestStreamLoops(t *testing.T) {
+	tm := testModule{rowsPerPattern: 4, orders: 1, notes: []int16{1}}
+	m, err := Parse(bytes.NewReader(compress(t, tm.encode(t))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 6000*bytesPerFrame)
+	_, err = io.ReadFull(NewStream(m, 6000, true), buf)
+	if err != nil {
+		t.Errorf("looping stream ended: %v", err)
+	}
+	silent := true
+	for _, b := range buf[len(buf)/2:] {
+		if b != 0 {
+			silent = false
+			break
+		}
+	}
+	if silent {
+		t.Error("looping stream is silent after the first loop")
+	}
+}
+
+func TestStreamShippedModules(t *testing.T) {
+	tests := []struct {
+		name string
+		want time.Duration
+	}{
+		{"backgroundmusic_1", 69100 * time.Millisecond},
+		{"gameover", 3200 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile("../../assets/sfx/" + tt.name + ".dmf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := Parse(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := duration(t, NewStream(m, 44100, false), 44100)
+		if got < tt.want-50*time.Millisecond || got > tt.want+50*time.Millisecond {
+			t.Errorf("%s plays for %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}